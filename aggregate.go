@@ -0,0 +1,284 @@
+package tql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// tagOptionPK marks a field as (part of) the key which identifies the struct
+	// when aggregating joined rows.
+	tagOptionPK = "pk"
+
+	// tagOptionMany marks a slice of structs field which is populated from
+	// the joined rows belonging to the same parent.
+	tagOptionMany = "many"
+)
+
+// aggregation
+// Describes how the rows of a one-to-many join are grouped into parent structs.
+//
+// A parent type is aggregated if at least one of its fields is a slice of structs tagged
+// with the 'many' option, e.g. `db:"orders,many"`. Parents are identified by the fields
+// tagged with the 'pk' option, e.g. `db:"id,pk"`.
+type aggregation struct {
+	fields    map[string]int
+	keys      []int
+	relations []relation
+}
+
+type relation struct {
+	name     string
+	field    int
+	elemType reflect.Type
+	fields   map[string]int
+	keys     []int
+}
+
+type columnTarget struct {
+	relation int
+	field    int
+}
+
+const parentTarget = -1
+
+var aggregations sync.Map // map[reflect.Type]*aggregation
+
+// typeAggregation
+// Returns the cached aggregation for typ, or nil if typ does not contain any 'many' fields,
+// resolving it if it is not cached.
+func typeAggregation(typ reflect.Type) (*aggregation, error) {
+	if agg, found := aggregations.Load(typ); found {
+		return agg.(*aggregation), nil
+	}
+
+	agg, err := resolveAggregation(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := aggregations.LoadOrStore(typ, agg)
+	return actual.(*aggregation), nil
+}
+
+// resolveAggregation
+// Resolves the aggregation for typ from the tags of its fields.
+func resolveAggregation(typ reflect.Type) (*aggregation, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	agg := aggregation{fields: make(map[string]int, typ.NumField())}
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		tag, foundTag := field.Tag.Lookup("db")
+		if !foundTag {
+			continue
+		}
+
+		name, opts := parseTag(tag)
+
+		if !slices.Contains(opts, tagOptionMany) {
+			agg.fields[name] = i
+			if slices.Contains(opts, tagOptionPK) {
+				agg.keys = append(agg.keys, i)
+			}
+			continue
+		}
+
+		if field.Type.Kind() != reflect.Slice || field.Type.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("field %s tagged with 'many' must be a slice of structs", field.Name)
+		}
+
+		elemType := field.Type.Elem()
+		rel := relation{
+			name:     name,
+			field:    i,
+			elemType: elemType,
+			fields:   make(map[string]int, elemType.NumField()),
+		}
+
+		for j := range elemType.NumField() {
+			elemField := elemType.Field(j)
+
+			elemTag, foundElemTag := elemField.Tag.Lookup("db")
+			if !foundElemTag {
+				continue
+			}
+
+			elemName, elemOpts := parseTag(elemTag)
+			if slices.Contains(elemOpts, tagOptionMany) {
+				return nil, fmt.Errorf("nested 'many' field %s is not supported", elemField.Name)
+			}
+
+			rel.fields[elemName] = j
+			if slices.Contains(elemOpts, tagOptionPK) {
+				rel.keys = append(rel.keys, j)
+			}
+		}
+
+		agg.relations = append(agg.relations, rel)
+	}
+
+	if len(agg.relations) == 0 {
+		return nil, nil
+	}
+
+	if len(agg.keys) == 0 {
+		return nil, fmt.Errorf("type %s has a 'many' field but no fields tagged with 'pk'", typ.Name())
+	}
+
+	return &agg, nil
+}
+
+// resolveColumns
+// Maps the columns to the parent or relation fields. Columns prefixed with the name of the relation
+// (e.g. "orders.id") are always mapped to the relation. Columns without a prefix are mapped
+// to the parent first, and to the first relation containing the field otherwise.
+func (agg *aggregation) resolveColumns(columns []string) ([]columnTarget, error) {
+	targets := make([]columnTarget, len(columns))
+
+ColumnLoop:
+	for i, c := range columns {
+		if prefix, name, found := strings.Cut(c, "."); found {
+			for r, rel := range agg.relations {
+				if rel.name != prefix {
+					continue
+				}
+
+				fieldIdx, foundField := rel.fields[name]
+				if !foundField {
+					return nil, fmt.Errorf("no matching field found for column: %s", c)
+				}
+
+				targets[i] = columnTarget{relation: r, field: fieldIdx}
+				continue ColumnLoop
+			}
+		}
+
+		if fieldIdx, foundField := agg.fields[c]; foundField {
+			targets[i] = columnTarget{relation: parentTarget, field: fieldIdx}
+			continue
+		}
+
+		for r, rel := range agg.relations {
+			if fieldIdx, foundField := rel.fields[c]; foundField {
+				targets[i] = columnTarget{relation: r, field: fieldIdx}
+				continue ColumnLoop
+			}
+		}
+
+		return nil, fmt.Errorf("no matching field found for column: %s", c)
+	}
+
+	return targets, nil
+}
+
+// scanAggregated
+// Scans the rows of a one-to-many join, appending a single parent per distinct key to result,
+// and the rows' children to the parents' 'many' fields. Children whose columns are all NULL
+// (e.g. from a LEFT JOIN without a match) are skipped, and children with 'pk' fields are
+// only added to the same parent once.
+func scanAggregated[T any](rows *sql.Rows, result []T, agg *aggregation) ([]T, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return result, err
+	}
+
+	targets, err := agg.resolveColumns(cols)
+	if err != nil {
+		return result, err
+	}
+
+	parents := make(map[string]int)
+	children := make(map[string]struct{})
+
+	dest := make([]any, len(cols))
+
+	for rows.Next() {
+		var current T
+		parent := reflect.ValueOf(&current).Elem()
+
+		for i, target := range targets {
+			if target.relation == parentTarget {
+				dest[i] = parent.Field(target.field).Addr().Interface()
+				continue
+			}
+
+			// Scan into a pointer to the field type, so NULLs from outer joins can be detected.
+			fieldType := agg.relations[target.relation].elemType.Field(target.field).Type
+			dest[i] = reflect.New(reflect.PointerTo(fieldType)).Interface()
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return result, err
+		}
+
+		key := rowKey(parent, agg.keys)
+
+		idx, found := parents[key]
+		if !found {
+			result = append(result, current)
+			idx = len(result) - 1
+			parents[key] = idx
+		}
+
+		target := reflect.ValueOf(&result[idx]).Elem()
+
+		for r, rel := range agg.relations {
+			child := reflect.New(rel.elemType).Elem()
+			isNull := true
+
+			for i, t := range targets {
+				if t.relation != r {
+					continue
+				}
+
+				value := reflect.ValueOf(dest[i]).Elem()
+				if value.IsNil() {
+					continue
+				}
+
+				isNull = false
+				child.Field(t.field).Set(value.Elem())
+			}
+
+			if isNull {
+				continue
+			}
+
+			if len(rel.keys) > 0 {
+				childKey := strings.Join([]string{key, rel.name, rowKey(child, rel.keys)}, "/")
+				if _, exists := children[childKey]; exists {
+					continue
+				}
+				children[childKey] = struct{}{}
+			}
+
+			slice := target.Field(rel.field)
+			slice.Set(reflect.Append(slice, child))
+		}
+	}
+
+	return result, rows.Err()
+}
+
+func rowKey(value reflect.Value, keys []int) string {
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		field := value.Field(k)
+		if field.Kind() == reflect.Pointer && field.IsNil() {
+			continue
+		}
+
+		values[i] = reflect.Indirect(field).Interface()
+	}
+
+	return fmt.Sprintf("%#v", values)
+}
@@ -0,0 +1,100 @@
+package tql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type testOrder struct {
+	ID     string `db:"id,pk"`
+	Amount int    `db:"amount"`
+}
+
+type testCustomer struct {
+	ID     string      `db:"id,pk"`
+	Name   string      `db:"name"`
+	Orders []testOrder `db:"orders,many"`
+}
+
+func Test_TypeAggregation_Caches_Aggregation(t *testing.T) {
+	// Act
+	first, err := typeAggregation(reflect.TypeFor[testCustomer]())
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	second, err := typeAggregation(reflect.TypeFor[testCustomer]())
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	// Assert
+	if first == nil || first != second {
+		t.Fatalf("expected the cached aggregation to be returned")
+	}
+
+	if len(first.relations) != 1 || first.relations[0].name != "orders" {
+		t.Fatalf("unexpected relations '%v'", first.relations)
+	}
+}
+
+func Test_Query_Aggregates_Joined_Rows_Into_Parents(t *testing.T) {
+	// Arrange
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name", "orders.id", "amount"},
+		rows: [][]driver.Value{
+			{"1", "foo", "a", int64(10)},
+			{"1", "foo", "b", int64(20)},
+			{"2", "bar", nil, nil},
+			{"1", "foo", "b", int64(20)},
+		},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	customers, err := Query[testCustomer](context.Background(), db, "SELECT ...;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(customers) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(customers))
+	}
+
+	if len(customers[0].Orders) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(customers[0].Orders))
+	}
+	if customers[0].Orders[1].ID != "b" || customers[0].Orders[1].Amount != 20 {
+		t.Fatalf("unexpected value '%v'", customers[0].Orders[1])
+	}
+
+	if customers[1].Name != "bar" || len(customers[1].Orders) != 0 {
+		t.Fatalf("unexpected value '%v'", customers[1])
+	}
+}
+
+func Test_Query_Aggregation_Requires_Primary_Key(t *testing.T) {
+	// Arrange
+	type customer struct {
+		ID     string      `db:"id"`
+		Orders []testOrder `db:"orders,many"`
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "orders.id"},
+		rows:    [][]driver.Value{{"1", "a"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, err := Query[customer](context.Background(), db, "SELECT ...;")
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
@@ -0,0 +1,545 @@
+package tql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition
+// A part of a WHERE, HAVING or JOIN ... ON clause, created using the functions such as tql.Eq, tql.And
+// and tql.Or. Nil conditions, and groups without any conditions, are skipped when rendering, so
+// optional filters can be collected in a slice and passed to Where as a whole.
+type Condition interface {
+	empty() bool
+	render(w *sqlWriter) error
+}
+
+// sqlWriter
+// Accumulates the rendered statement and its arguments, so the positional parameters
+// of nested conditions and subqueries are numbered consistently.
+type sqlWriter struct {
+	dialect Dialect
+	sql     strings.Builder
+	args    []any
+}
+
+func (w *sqlWriter) write(s ...string) {
+	for _, part := range s {
+		w.sql.WriteString(part)
+	}
+}
+
+// param
+// Writes the value as a positional parameter, or as a parenthesised subquery if
+// the value is a *SelectBuilder.
+func (w *sqlWriter) param(value any) error {
+	if sub, ok := value.(*SelectBuilder); ok {
+		w.write("(")
+		if err := sub.render(w); err != nil {
+			return err
+		}
+		w.write(")")
+		return nil
+	}
+
+	w.write(w.dialect.Placeholder(len(w.args) + 1))
+	w.args = append(w.args, value)
+	return nil
+}
+
+func isEmpty(c Condition) bool {
+	return c == nil || c.empty()
+}
+
+type comparison struct {
+	column   string
+	operator string
+	value    any
+}
+
+func (c comparison) empty() bool { return false }
+
+func (c comparison) render(w *sqlWriter) error {
+	w.write(c.column, " ", c.operator, " ")
+	return w.param(c.value)
+}
+
+// Eq renders column = value. The value can be a *SelectBuilder, which is rendered as a subquery.
+func Eq(column string, value any) Condition { return comparison{column, "=", value} }
+
+// Ne renders column <> value.
+func Ne(column string, value any) Condition { return comparison{column, "<>", value} }
+
+// Lt renders column < value.
+func Lt(column string, value any) Condition { return comparison{column, "<", value} }
+
+// Lte renders column <= value.
+func Lte(column string, value any) Condition { return comparison{column, "<=", value} }
+
+// Gt renders column > value.
+func Gt(column string, value any) Condition { return comparison{column, ">", value} }
+
+// Gte renders column >= value.
+func Gte(column string, value any) Condition { return comparison{column, ">=", value} }
+
+// Like renders column LIKE value.
+func Like(column string, value any) Condition { return comparison{column, "LIKE", value} }
+
+type nullCheck struct {
+	column string
+	not    bool
+}
+
+func (c nullCheck) empty() bool { return false }
+
+func (c nullCheck) render(w *sqlWriter) error {
+	if c.not {
+		w.write(c.column, " IS NOT NULL")
+	} else {
+		w.write(c.column, " IS NULL")
+	}
+	return nil
+}
+
+// IsNull renders column IS NULL.
+func IsNull(column string) Condition { return nullCheck{column: column} }
+
+// IsNotNull renders column IS NOT NULL.
+func IsNotNull(column string) Condition { return nullCheck{column: column, not: true} }
+
+type in struct {
+	column string
+	values []any
+}
+
+func (c in) empty() bool { return false }
+
+func (c in) render(w *sqlWriter) error {
+	if len(c.values) == 1 {
+		if sub, ok := c.values[0].(*SelectBuilder); ok {
+			w.write(c.column, " IN ")
+			return w.param(sub)
+		}
+	}
+
+	// IN () is not valid SQL, and matches nothing anyway.
+	if len(c.values) == 0 {
+		w.write("1 = 0")
+		return nil
+	}
+
+	w.write(c.column, " IN (")
+	for i, v := range c.values {
+		if i > 0 {
+			w.write(", ")
+		}
+
+		if err := w.param(v); err != nil {
+			return err
+		}
+	}
+	w.write(")")
+
+	return nil
+}
+
+// In renders column IN (values...). A single slice value is expanded into its elements,
+// and a single *SelectBuilder value is rendered as a subquery.
+func In(column string, values ...any) Condition {
+	if len(values) == 1 {
+		if _, isBytes := values[0].([]byte); !isBytes {
+			if v := reflect.ValueOf(values[0]); v.Kind() == reflect.Slice {
+				expanded := make([]any, v.Len())
+				for i := range v.Len() {
+					expanded[i] = v.Index(i).Interface()
+				}
+				values = expanded
+			}
+		}
+	}
+
+	return in{column: column, values: values}
+}
+
+type exists struct {
+	query *SelectBuilder
+}
+
+func (c exists) empty() bool { return false }
+
+func (c exists) render(w *sqlWriter) error {
+	w.write("EXISTS ")
+	return w.param(c.query)
+}
+
+// Exists renders EXISTS (subquery).
+func Exists(query *SelectBuilder) Condition { return exists{query: query} }
+
+type group struct {
+	operator   string
+	conditions []Condition
+}
+
+func (g group) empty() bool {
+	for _, c := range g.conditions {
+		if !isEmpty(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g group) render(w *sqlWriter) error {
+	conditions := make([]Condition, 0, len(g.conditions))
+	for _, c := range g.conditions {
+		if !isEmpty(c) {
+			conditions = append(conditions, c)
+		}
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0].render(w)
+	}
+
+	w.write("(")
+	if err := renderConditions(w, g.operator, conditions); err != nil {
+		return err
+	}
+	w.write(")")
+
+	return nil
+}
+
+// And groups the conditions with AND.
+func And(conditions ...Condition) Condition { return group{operator: "AND", conditions: conditions} }
+
+// Or groups the conditions with OR.
+func Or(conditions ...Condition) Condition { return group{operator: "OR", conditions: conditions} }
+
+type not struct {
+	condition Condition
+}
+
+func (c not) empty() bool { return isEmpty(c.condition) }
+
+func (c not) render(w *sqlWriter) error {
+	w.write("NOT (")
+	if err := c.condition.render(w); err != nil {
+		return err
+	}
+	w.write(")")
+	return nil
+}
+
+// Not renders NOT (condition).
+func Not(condition Condition) Condition { return not{condition: condition} }
+
+type raw struct {
+	sql  string
+	args []any
+}
+
+func (c raw) empty() bool { return c.sql == "" }
+
+func (c raw) render(w *sqlWriter) error {
+	if strings.Count(c.sql, "?") != len(c.args) {
+		return fmt.Errorf("raw condition '%s' expects %d arguments, got %d", c.sql, strings.Count(c.sql, "?"), len(c.args))
+	}
+
+	argIdx := 0
+	for _, r := range c.sql {
+		if r != '?' {
+			w.sql.WriteRune(r)
+			continue
+		}
+
+		if err := w.param(c.args[argIdx]); err != nil {
+			return err
+		}
+		argIdx++
+	}
+
+	return nil
+}
+
+// Raw renders the sql as is, with every ? replaced by the next argument in the positional
+// parameter syntax of the dialect. Useful for join conditions, e.g. Raw("o.customer_id = c.id").
+func Raw(sql string, args ...any) Condition { return raw{sql: sql, args: args} }
+
+func renderConditions(w *sqlWriter, operator string, conditions []Condition) error {
+	written := 0
+	for _, c := range conditions {
+		if isEmpty(c) {
+			continue
+		}
+
+		if written > 0 {
+			w.write(" ", operator, " ")
+		}
+
+		if err := c.render(w); err != nil {
+			return err
+		}
+		written++
+	}
+
+	return nil
+}
+
+func renderWhere(w *sqlWriter, keyword string, conditions []Condition) error {
+	if isEmpty(And(conditions...)) {
+		return nil
+	}
+
+	w.write(" ", keyword, " ")
+	return renderConditions(w, "AND", conditions)
+}
+
+type join struct {
+	kind  string
+	table string
+	on    Condition
+}
+
+// SelectBuilder
+// Builds a SELECT statement. Table and column names are written into the statement as is,
+// and *must not* come from user input. Values are always passed as parameters.
+type SelectBuilder struct {
+	columns   []string
+	from      string
+	fromQuery *SelectBuilder
+	fromAlias string
+	joins     []join
+	where     []Condition
+	groupBy   []string
+	having    []Condition
+	orderBy   []string
+	limit     int
+	offset    int
+}
+
+// Select
+// Starts building a SELECT statement of the columns. Selects * if no columns are provided.
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns, limit: -1, offset: -1}
+}
+
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	return b
+}
+
+// FromSelect selects from the subquery, aliased as alias.
+func (b *SelectBuilder) FromSelect(query *SelectBuilder, alias string) *SelectBuilder {
+	b.fromQuery = query
+	b.fromAlias = alias
+	return b
+}
+
+func (b *SelectBuilder) Join(table string, on Condition) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "JOIN", table: table, on: on})
+	return b
+}
+
+func (b *SelectBuilder) LeftJoin(table string, on Condition) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "LEFT JOIN", table: table, on: on})
+	return b
+}
+
+func (b *SelectBuilder) RightJoin(table string, on Condition) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "RIGHT JOIN", table: table, on: on})
+	return b
+}
+
+// Where
+// Adds the conditions to the WHERE clause. All the conditions, including the ones
+// from previous calls, are joined with AND.
+func (b *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+func (b *SelectBuilder) Having(conditions ...Condition) *SelectBuilder {
+	b.having = append(b.having, conditions...)
+	return b
+}
+
+// OrderBy adds the columns to the ORDER BY clause, e.g. OrderBy("created_at DESC", "id").
+func (b *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, columns...)
+	return b
+}
+
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// Build
+// Renders the statement in the dialect d and returns it together with its arguments, ready
+// to be passed to tql.Query and its variants. Use DialectOf to get the dialect of a database handle.
+func (b *SelectBuilder) Build(d Dialect) (string, []any, error) {
+	w := sqlWriter{dialect: d}
+	if err := b.render(&w); err != nil {
+		return "", nil, err
+	}
+
+	return w.sql.String(), w.args, nil
+}
+
+func (b *SelectBuilder) render(w *sqlWriter) error {
+	w.write("SELECT ")
+	if len(b.columns) == 0 {
+		w.write("*")
+	} else {
+		w.write(strings.Join(b.columns, ", "))
+	}
+
+	switch {
+	case b.fromQuery != nil:
+		w.write(" FROM ")
+		if err := w.param(b.fromQuery); err != nil {
+			return err
+		}
+		// Oracle doesn't support AS before the alias of a table.
+		w.write(" ", b.fromAlias)
+	case b.from != "":
+		w.write(" FROM ", b.from)
+	default:
+		return fmt.Errorf("select statement is missing the FROM clause")
+	}
+
+	for _, j := range b.joins {
+		w.write(" ", j.kind, " ", j.table)
+		if isEmpty(j.on) {
+			continue
+		}
+
+		w.write(" ON ")
+		if err := j.on.render(w); err != nil {
+			return err
+		}
+	}
+
+	if err := renderWhere(w, "WHERE", b.where); err != nil {
+		return err
+	}
+
+	if len(b.groupBy) > 0 {
+		w.write(" GROUP BY ", strings.Join(b.groupBy, ", "))
+	}
+
+	if err := renderWhere(w, "HAVING", b.having); err != nil {
+		return err
+	}
+
+	if len(b.orderBy) > 0 {
+		w.write(" ORDER BY ", strings.Join(b.orderBy, ", "))
+	}
+
+	if b.limit >= 0 || b.offset >= 0 {
+		w.write(" ", w.dialect.Limit(b.limit, b.offset))
+	}
+
+	return nil
+}
+
+type assignment struct {
+	column string
+	value  any
+}
+
+// UpdateBuilder
+// Builds an UPDATE statement. Table and column names are written into the statement as is,
+// and *must not* come from user input. Values are always passed as parameters.
+type UpdateBuilder struct {
+	table string
+	set   []assignment
+	where []Condition
+}
+
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set sets the column to the value. The value can be a *SelectBuilder, which is rendered as a subquery.
+func (b *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	b.set = append(b.set, assignment{column: column, value: value})
+	return b
+}
+
+func (b *UpdateBuilder) Where(conditions ...Condition) *UpdateBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Build
+// Renders the statement in the dialect d and returns it together with its arguments, ready
+// to be passed to tql.Exec.
+func (b *UpdateBuilder) Build(d Dialect) (string, []any, error) {
+	if len(b.set) == 0 {
+		return "", nil, fmt.Errorf("update statement is missing the SET clause")
+	}
+
+	w := sqlWriter{dialect: d}
+	w.write("UPDATE ", b.table, " SET ")
+
+	for i, a := range b.set {
+		if i > 0 {
+			w.write(", ")
+		}
+
+		w.write(a.column, " = ")
+		if err := w.param(a.value); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := renderWhere(&w, "WHERE", b.where); err != nil {
+		return "", nil, err
+	}
+
+	return w.sql.String(), w.args, nil
+}
+
+// DeleteBuilder
+// Builds a DELETE statement. The table name is written into the statement as is,
+// and *must not* come from user input. Values are always passed as parameters.
+type DeleteBuilder struct {
+	table string
+	where []Condition
+}
+
+func DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+func (b *DeleteBuilder) Where(conditions ...Condition) *DeleteBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Build
+// Renders the statement in the dialect d and returns it together with its arguments, ready
+// to be passed to tql.Exec.
+func (b *DeleteBuilder) Build(d Dialect) (string, []any, error) {
+	w := sqlWriter{dialect: d}
+	w.write("DELETE FROM ", b.table)
+
+	if err := renderWhere(&w, "WHERE", b.where); err != nil {
+		return "", nil, err
+	}
+
+	return w.sql.String(), w.args, nil
+}
@@ -0,0 +1,157 @@
+package tql
+
+import "testing"
+
+func Test_Postgres_Select_Builder(t *testing.T) {
+	// Arrange
+	sub := Select("customer_id").From("orders").Where(Gt("amount", 100))
+
+	// Act
+	query, args, err := Select("c.id", "c.value").
+		From("customer c").
+		LeftJoin("address a", Raw("a.customer_id = c.id")).
+		Where(
+			Eq("c.value", "foo"),
+			Or(Lt("c.age", 18), And(Gte("c.age", 65), IsNotNull("a.id"))),
+			In("c.id", sub),
+			In("c.kind", []string{"a", "b"}),
+		).
+		Where(nil, And(), Or(nil)).
+		OrderBy("c.id DESC").
+		Limit(10).
+		Offset(20).
+		Build(Postgres)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT c.id, c.value FROM customer c LEFT JOIN address a ON a.customer_id = c.id " +
+		"WHERE c.value = $1 AND (c.age < $2 OR (c.age >= $3 AND a.id IS NOT NULL)) " +
+		"AND c.id IN (SELECT customer_id FROM orders WHERE amount > $4) AND c.kind IN ($5, $6) " +
+		"ORDER BY c.id DESC LIMIT 10 OFFSET 20"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	expectedArgsLen := 6
+	if len(args) != expectedArgsLen {
+		t.Fatalf("expected len %d found %d", expectedArgsLen, len(args))
+	}
+
+	if args[3] != 100 || args[5] != "b" {
+		t.Fatalf("unexpected args '%v'", args)
+	}
+}
+
+func Test_Postgres_Select_Builder_Without_Conditions(t *testing.T) {
+	// Arrange
+	var conditions []Condition
+
+	// Act
+	query, args, err := Select().From("foo").Where(conditions...).Build(Postgres)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT * FROM foo"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	if len(args) != 0 {
+		t.Fatalf("expected len %d found %d", 0, len(args))
+	}
+}
+
+func Test_Postgres_Update_And_Delete_Builders(t *testing.T) {
+	// Act
+	update, updateArgs, updateErr := Update("foo").
+		Set("value", "bar").
+		Set("updated_by", Select("id").From("users").Where(Eq("name", "baz"))).
+		Where(Eq("id", 1)).
+		Build(Postgres)
+
+	del, deleteArgs, deleteErr := DeleteFrom("foo").Where(Not(Eq("id", 1))).Build(Postgres)
+
+	// Assert
+	if updateErr != nil {
+		t.Fatalf("unexpected err: %s", updateErr.Error())
+	}
+
+	const expectedUpdate = "UPDATE foo SET value = $1, updated_by = (SELECT id FROM users WHERE name = $2) WHERE id = $3"
+	if update != expectedUpdate {
+		t.Fatalf("value '%s' does not equal expected '%s'", update, expectedUpdate)
+	}
+
+	if len(updateArgs) != 3 {
+		t.Fatalf("expected len %d found %d", 3, len(updateArgs))
+	}
+
+	if deleteErr != nil {
+		t.Fatalf("unexpected err: %s", deleteErr.Error())
+	}
+
+	const expectedDelete = "DELETE FROM foo WHERE NOT (id = $1)"
+	if del != expectedDelete {
+		t.Fatalf("value '%s' does not equal expected '%s'", del, expectedDelete)
+	}
+
+	if len(deleteArgs) != 1 {
+		t.Fatalf("expected len %d found %d", 1, len(deleteArgs))
+	}
+}
+
+func Test_MySQL_Select_Builder(t *testing.T) {
+	// Act
+	query, args, err := Select("id").
+		From("foo").
+		Where(Eq("value", "bar"), In("kind", "a", "b")).
+		Offset(20).
+		Build(MySQL)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT id FROM foo WHERE value = ? AND kind IN (?, ?) LIMIT 18446744073709551615 OFFSET 20"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	if len(args) != 3 {
+		t.Fatalf("expected len %d found %d", 3, len(args))
+	}
+}
+
+func Test_Oracle_Select_Builder_From_Select(t *testing.T) {
+	// Arrange
+	sub := Select("customer_id", "amount").From("orders").Where(Gt("amount", 100))
+
+	// Act
+	query, args, err := Select("o.customer_id").
+		FromSelect(sub, "o").
+		Where(Eq("o.customer_id", "1")).
+		Limit(10).
+		Offset(20).
+		Build(Oracle)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT o.customer_id FROM (SELECT customer_id, amount FROM orders WHERE amount > :1) o " +
+		"WHERE o.customer_id = :2 OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	if len(args) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(args))
+	}
+}
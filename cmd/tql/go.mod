@@ -0,0 +1,23 @@
+module github.com/emanuel-skrenkovic/tql/cmd/tql
+
+go 1.24
+
+require (
+	github.com/emanuel-skrenkovic/tql v0.0.2
+	github.com/go-sql-driver/mysql v1.6.0
+	github.com/jackc/pgx/v5 v5.5.4
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.17
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/puddle/v2 v2.2.1 // indirect
+	github.com/stretchr/testify v1.10.0 // indirect
+	golang.org/x/crypto v0.35.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+)
+
+replace github.com/emanuel-skrenkovic/tql => ../../
@@ -0,0 +1,39 @@
+// Command tql runs the migrations of the migrate package from the command line.
+//
+//	tql migrate [flags] up|down [n]|status|create <name>|verify
+//
+// The database is selected using the -driver and -dsn flags. The postgres (lib/pq), pgx, mysql and sqlite3
+// drivers are registered.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage:
+	tql migrate [flags] up             applies the pending migrations
+	tql migrate [flags] down [n]       reverts the last n applied migrations (default 1)
+	tql migrate [flags] status         lists the migrations and whether they are applied
+	tql migrate [flags] create <name>  creates the next <version>.<name>.up.sql and .down.sql files
+	tql migrate [flags] verify         checks the applied migrations for drift
+
+Flags:
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "migrate" {
+		_, _ = fmt.Fprint(stderr, usage)
+		return fmt.Errorf("expected the migrate command")
+	}
+
+	return runMigrate(args[1:], stdout, stderr)
+}
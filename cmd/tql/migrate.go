@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/emanuel-skrenkovic/tql/migrate"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// defaultVersionWidth is the number of digits of the versions created in an empty directory.
+const defaultVersionWidth = 3
+
+var (
+	driverDialects = map[string]migrate.Dialect{
+		"postgres": migrate.Postgres,
+		"pgx":      migrate.Postgres,
+		"mysql":    migrate.MySQL,
+		"sqlite3":  migrate.SQLite,
+	}
+
+	migrationNameRegex    = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	migrationVersionRegex = regexp.MustCompile(`^(\d+)\.[^.]+\.(up|down)\.sql$`)
+)
+
+type migrateConfig struct {
+	driver      string
+	dsn         string
+	dialect     string
+	dir         string
+	dryRun      bool
+	lockTimeout time.Duration
+}
+
+func runMigrate(args []string, stdout, stderr io.Writer) error {
+	var cfg migrateConfig
+
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&cfg.driver, "driver", "", "database/sql driver name: postgres, pgx, mysql or sqlite3")
+	flags.StringVar(&cfg.dsn, "dsn", "", "data source name passed to the driver")
+	flags.StringVar(&cfg.dialect, "dialect", "", "migration dialect, derived from the driver if empty (e.g. cockroachdb)")
+	flags.StringVar(&cfg.dir, "dir", "migrations", "directory containing the migrations")
+	flags.BoolVar(&cfg.dryRun, "dry-run", false, "print the SQL that would be applied instead of applying it")
+	flags.DurationVar(&cfg.lockTimeout, "lock-timeout", 5*time.Minute, "how long to wait for the migration lock")
+
+	// Flags are accepted both before and after the subcommand.
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+
+		if flags.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(positional) == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected a migrate subcommand")
+	}
+
+	command, commandArgs := positional[0], positional[1:]
+
+	if command == "create" {
+		if len(commandArgs) != 1 {
+			return fmt.Errorf("usage: tql migrate create <name>")
+		}
+		return createMigration(cfg.dir, commandArgs[0], cfg.dryRun, stdout)
+	}
+
+	ctx := context.Background()
+
+	m, db, err := openMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	switch command {
+	case "up":
+		if len(commandArgs) != 0 {
+			return fmt.Errorf("usage: tql migrate up")
+		}
+		return up(ctx, m, cfg.dryRun, stdout)
+	case "down":
+		n := 1
+		if len(commandArgs) > 1 {
+			return fmt.Errorf("usage: tql migrate down [n]")
+		}
+		if len(commandArgs) == 1 {
+			if n, err = strconv.Atoi(commandArgs[0]); err != nil {
+				return fmt.Errorf("invalid number of migrations to revert: %s", commandArgs[0])
+			}
+		}
+		return down(ctx, m, n, cfg.dryRun, stdout)
+	case "status":
+		return status(ctx, m, stdout)
+	case "verify":
+		if err := m.Verify(ctx); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(stdout, "migrations match the applied migrations")
+		return err
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate subcommand: %s", command)
+	}
+}
+
+func openMigrator(cfg migrateConfig) (*migrate.Migrator, *sql.DB, error) {
+	if cfg.driver == "" || cfg.dsn == "" {
+		return nil, nil, fmt.Errorf("both -driver and -dsn are required")
+	}
+
+	dialect := migrate.Dialect(cfg.dialect)
+	if dialect == "" {
+		var found bool
+		if dialect, found = driverDialects[cfg.driver]; !found {
+			return nil, nil, fmt.Errorf("failed to find the migration dialect of driver %s, use -dialect", cfg.driver)
+		}
+	}
+
+	db, err := sql.Open(cfg.driver, cfg.dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := migrate.New(db, dialect, os.DirFS(cfg.dir), migrate.WithLockTimeout(cfg.lockTimeout))
+	if err != nil {
+		return nil, nil, errors.Join(err, db.Close())
+	}
+
+	return m, db, nil
+}
+
+func up(ctx context.Context, m *migrate.Migrator, dryRun bool, stdout io.Writer) error {
+	if !dryRun {
+		return m.Up(ctx)
+	}
+
+	migrations, repeatables, err := m.PlanUp(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if _, err := fmt.Fprintf(
+			stdout,
+			"-- %d.%s.up.sql\n%s\n\n",
+			migration.Version,
+			migration.Name,
+			strings.TrimSpace(migration.UpScript),
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range repeatables {
+		if _, err := fmt.Fprintf(stdout, "-- R.%s.sql\n%s\n\n", r.Name, strings.TrimSpace(r.Script)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func down(ctx context.Context, m *migrate.Migrator, n int, dryRun bool, stdout io.Writer) error {
+	if !dryRun {
+		return m.Down(ctx, n)
+	}
+
+	migrations, err := m.PlanDown(ctx, n)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if _, err := fmt.Fprintf(
+			stdout,
+			"-- %d.%s.down.sql\n%s\n\n",
+			migration.Version,
+			migration.Name,
+			strings.TrimSpace(migration.DownScript),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func status(ctx context.Context, m *migrate.Migrator, stdout io.Writer) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+
+	for _, s := range statuses {
+		version := strconv.Itoa(s.Version)
+		if s.Repeatable {
+			version = "R"
+		}
+
+		state := "pending"
+		switch {
+		case s.Applied && s.Changed:
+			state = "changed"
+		case s.Applied:
+			state = "applied"
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", version, s.Name, state)
+	}
+
+	return w.Flush()
+}
+
+// createMigration
+// Creates the up and down scripts of the migration following the newest migration in dir.
+// The version is zero-padded to the width of the existing versions.
+func createMigration(dir, name string, dryRun bool, stdout io.Writer) error {
+	if !migrationNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid migration name %q, only letters, digits and underscores are allowed", name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	latest, width := 0, defaultVersionWidth
+	for _, entry := range entries {
+		match := migrationVersionRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		if version >= latest {
+			latest, width = version, len(match[1])
+		}
+	}
+
+	prefix := fmt.Sprintf("%0*d.%s", width, latest+1, name)
+	files := []string{
+		filepath.Join(dir, prefix+".up.sql"),
+		filepath.Join(dir, prefix+".down.sql"),
+	}
+
+	if dryRun {
+		for _, file := range files {
+			if _, err := fmt.Fprintf(stdout, "would create %s\n", file); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+
+		header := fmt.Sprintf("-- %s\n", filepath.Base(file))
+		if _, err := f.WriteString(header); err != nil {
+			return errors.Join(err, f.Close())
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(stdout, "created %s\n", file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
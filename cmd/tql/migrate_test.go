@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func Test_Migrate_Create_Numbers_Migrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		expected []string
+	}{
+		{
+			name:     "empty directory",
+			existing: nil,
+			expected: []string{"001.add_users.down.sql", "001.add_users.up.sql"},
+		},
+		{
+			name:     "keeps the width of the existing versions",
+			existing: []string{"0009.create_foo.up.sql", "0009.create_foo.down.sql", "R.foo_view.sql"},
+			expected: []string{
+				"0009.create_foo.down.sql",
+				"0009.create_foo.up.sql",
+				"0010.add_users.down.sql",
+				"0010.add_users.up.sql",
+				"R.foo_view.sql",
+			},
+		},
+		{
+			name:     "unpadded versions",
+			existing: []string{"1.create_foo.up.sql", "1.create_foo.down.sql", "2.create_bar.up.sql", "2.create_bar.down.sql"},
+			expected: []string{
+				"1.create_foo.down.sql",
+				"1.create_foo.up.sql",
+				"2.create_bar.down.sql",
+				"2.create_bar.up.sql",
+				"3.add_users.down.sql",
+				"3.add_users.up.sql",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Arrange
+			dir := t.TempDir()
+			for _, name := range test.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+					t.Fatalf("unexpected err: %s", err.Error())
+				}
+			}
+
+			var stdout, stderr bytes.Buffer
+
+			// Act
+			err := run([]string{"migrate", "-dir", dir, "create", "add_users"}, &stdout, &stderr)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err.Error())
+			}
+
+			if names := dirNames(t, dir); !slices.Equal(names, test.expected) {
+				t.Fatalf("value '%v' does not equal expected '%v'", names, test.expected)
+			}
+		})
+	}
+}
+
+func Test_Migrate_Create_Dry_Run_Does_Not_Create_Files(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	err := run([]string{"migrate", "create", "add_users", "-dir", dir, "--dry-run"}, &stdout, &stderr)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if names := dirNames(t, dir); len(names) != 0 {
+		t.Fatalf("expected no files found '%v'", names)
+	}
+
+	if !strings.Contains(stdout.String(), "would create "+filepath.Join(dir, "001.add_users.up.sql")) {
+		t.Fatalf("unexpected output '%s'", stdout.String())
+	}
+}
+
+func Test_Migrate_Create_Rejects_Invalid_Name(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	err := run([]string{"migrate", "-dir", t.TempDir(), "create", "add.users"}, &stdout, &stderr)
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_Migrate_Up_Dry_Run_Prints_Pending_Migrations(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	files := map[string]string{
+		"001.create_foo.up.sql":   "CREATE TABLE foo (id text);",
+		"001.create_foo.down.sql": "DROP TABLE foo;",
+		"002.create_bar.up.sql":   "CREATE TABLE bar (id text);",
+		"002.create_bar.down.sql": "DROP TABLE bar;",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("unexpected err: %s", err.Error())
+		}
+	}
+
+	dsn := filepath.Join(t.TempDir(), "tql.db")
+	args := []string{"migrate", "-driver", "sqlite3", "-dsn", dsn, "-dir", dir}
+
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	err := run(append(args, "--dry-run", "up"), &stdout, &stderr)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expected := "-- 1.create_foo.up.sql\nCREATE TABLE foo (id text);\n\n" +
+		"-- 2.create_bar.up.sql\nCREATE TABLE bar (id text);\n\n"
+	if stdout.String() != expected {
+		t.Fatalf("value '%s' does not equal expected '%s'", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	if err := run(append(args, "status"), &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if strings.Contains(stdout.String(), "applied") {
+		t.Fatalf("expected no applied migrations found '%s'", stdout.String())
+	}
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
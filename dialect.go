@@ -0,0 +1,475 @@
+package tql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"weak"
+)
+
+// Dialect
+// The SQL syntax of a database, used when translating the queries and building statements.
+// Register the dialects of drivers tql doesn't know about using RegisterDialect.
+type Dialect interface {
+	// Placeholder returns the n-th (1-based) positional parameter, e.g. $1 or ?.
+	Placeholder(n int) string
+	// QuoteIdentifier quotes a table or column name, e.g. "name" or `name`.
+	QuoteIdentifier(name string) string
+	// Limit returns the clause limiting the number of rows, e.g. LIMIT 10 OFFSET 20.
+	// Negative values are omitted, and an empty string is returned if both are negative.
+	Limit(limit, offset int) string
+	// Supports reports whether the database supports the feature.
+	Supports(feature Feature) bool
+}
+
+// Feature
+// An optional capability of a database, reported by Dialect.Supports.
+type Feature int
+
+const (
+	// FeatureReturning is INSERT/UPDATE/DELETE ... RETURNING.
+	FeatureReturning Feature = iota
+	// FeatureOnConflict is INSERT ... ON CONFLICT.
+	FeatureOnConflict
+	// FeatureLastInsertID is sql.Result.LastInsertId.
+	FeatureLastInsertID
+)
+
+var (
+	// Postgres is the dialect of Postgres and CockroachDB.
+	Postgres Dialect = postgresDialect{}
+	// MySQL is the dialect of MySQL and MariaDB.
+	MySQL Dialect = mySQLDialect{}
+	// SQLite is the dialect of SQLite.
+	SQLite Dialect = sqliteDialect{}
+	// SQLServer is the dialect of Microsoft SQL Server.
+	SQLServer Dialect = sqlServerDialect{}
+	// Oracle is the dialect of Oracle Database 12c and newer.
+	Oracle Dialect = oracleDialect{}
+	// DuckDB is the dialect of DuckDB.
+	DuckDB Dialect = duckDBDialect{}
+	// ClickHouse is the dialect of ClickHouse.
+	ClickHouse Dialect = clickHouseDialect{}
+)
+
+var dialects = struct {
+	sync.RWMutex
+	byDriver map[string]Dialect
+}{
+	byDriver: map[string]Dialect{
+		"postgres":         Postgres,
+		"pgx":              Postgres,
+		"pq-timeouts":      Postgres,
+		"cloudsqlpostgres": Postgres,
+		"ql":               Postgres,
+		"nrpostgres":       Postgres,
+		"cockroach":        Postgres,
+
+		"mysql":   MySQL,
+		"nrmysql": MySQL,
+
+		"sqlite3":   SQLite,
+		"nrsqlite3": SQLite,
+		"sqlite":    SQLite,
+
+		"sqlserver": SQLServer,
+		"azuresql":  SQLServer,
+
+		"godror": Oracle,
+		"oracle": Oracle,
+
+		"duckdb": DuckDB,
+
+		"clickhouse": ClickHouse,
+	},
+}
+
+// RegisterDialect
+// Sets the dialect used for the driver registered under driverName with database/sql.
+// Registering a dialect for a driver name which already has one replaces it.
+func RegisterDialect(driverName string, d Dialect) {
+	dialects.Lock()
+	defer dialects.Unlock()
+
+	dialects.byDriver[driverName] = d
+
+	// The drivers which were not detected before might be detected now.
+	detectedDialects.Clear()
+	driverTypes.Clear()
+}
+
+func dialectFor(driverName string) (Dialect, error) {
+	dialects.RLock()
+	defer dialects.RUnlock()
+
+	d, found := dialects.byDriver[driverName]
+	if !found {
+		return nil, fmt.Errorf("failed to find the dialect of driver %s, register it using tql.RegisterDialect", driverName)
+	}
+
+	return d, nil
+}
+
+// activeDialect
+// Returns the dialect of the driver set using SetActiveDriver, or of the only registered driver.
+func activeDialect() (Dialect, error) {
+	driverName := getActiveDriver()
+	if driverName == "" && len(sql.Drivers()) == 0 {
+		return nil, errors.New(
+			"tql: failed to choose the dialect, no drivers are registered, " +
+				"use a *sql.DB of a supported driver or wrap the handle using tql.WithDialect",
+		)
+	}
+
+	if driverName == "" {
+		return nil, errors.New(
+			"tql: failed to choose the dialect, multiple drivers are registered, " +
+				"use a *sql.DB of a supported driver, wrap the handle using tql.WithDialect or call tql.SetActiveDriver",
+		)
+	}
+
+	return dialectFor(driverName)
+}
+
+// Handle
+// A database handle which is both a Querier and an Executor, e.g. *sql.DB, *sql.Tx or *sql.Conn.
+type Handle interface {
+	Querier
+	Executor
+}
+
+// dialecter
+// A handle or a driver which knows its dialect, e.g. the handles returned by WithDialect. A nil
+// dialect means it doesn't know it, and the dialect is chosen as for any other handle.
+type dialecter interface {
+	Dialect() Dialect
+}
+
+// dialectHandle
+// A handle whose queries are translated using the dialect it was created with.
+type dialectHandle struct {
+	Handle
+	dialect Dialect
+}
+
+func (h dialectHandle) Dialect() Dialect { return h.dialect }
+
+// WithDialect
+// Wraps the handle so the queries run through it are translated using the dialect d. Use it for the
+// handles which don't expose their driver, e.g. *sql.Tx or *sql.Conn, when multiple drivers are registered:
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	// error handling
+//	d, err := tql.DialectOf(db)
+//	// error handling
+//	foos, err := tql.Query[Foo](ctx, tql.WithDialect(tx, d), "SELECT * FROM foo WHERE id = :id;", foo)
+func WithDialect(h Handle, d Dialect) Handle {
+	return dialectHandle{Handle: h, dialect: d}
+}
+
+// DialectOf
+// Returns the dialect used to translate the queries run through the handle.
+func DialectOf(handle any) (Dialect, error) {
+	return dialectOf(handle)
+}
+
+// driverPackages
+// The dialects of the drivers detected from the package of the driver type.
+var driverPackages = map[string]Dialect{
+	"github.com/lib/pq":              Postgres,
+	"github.com/jackc/pgx/stdlib":    Postgres,
+	"github.com/jackc/pgx/v4/stdlib": Postgres,
+	"github.com/jackc/pgx/v5/stdlib": Postgres,
+	"github.com/go-sql-driver/mysql": MySQL,
+	"github.com/mattn/go-sqlite3":    SQLite,
+	"modernc.org/sqlite":             SQLite,
+
+	"github.com/microsoft/go-mssqldb":        SQLServer,
+	"github.com/denisenkom/go-mssqldb":       SQLServer,
+	"github.com/godror/godror":               Oracle,
+	"github.com/sijms/go-ora/v2":             Oracle,
+	"github.com/marcboeker/go-duckdb":        DuckDB,
+	"github.com/marcboeker/go-duckdb/v2":     DuckDB,
+	"github.com/duckdb/duckdb-go/v2":         DuckDB,
+	"github.com/ClickHouse/clickhouse-go/v2": ClickHouse,
+}
+
+// detection
+// The result of detecting the dialect of a driver, the dialect is nil if it is unknown.
+type detection struct {
+	dialect Dialect
+}
+
+// detectedDialects
+// The dialects detected for every *sql.DB, including the ones which failed to be detected.
+// The entries are removed once the *sql.DB is garbage collected.
+var detectedDialects sync.Map // map[weak.Pointer[sql.DB]]detection
+
+// driverTypes
+// The dialects detected for every driver type, so the registered drivers are only searched
+// once for every type.
+var driverTypes sync.Map // map[reflect.Type]detection
+
+// driverer
+// A handle which exposes its driver, e.g. *sql.DB.
+type driverer interface {
+	Driver() driver.Driver
+}
+
+// dialectOf
+// Returns the dialect of the database handle. Handles created using WithDialect use their own
+// dialect. The dialect of a *sql.DB is detected from the type of its driver and cached. Other
+// handles, and the *sql.DB whose driver is unknown, use the dialect of the driver set using
+// SetActiveDriver, or of the only registered driver.
+func dialectOf(handle any) (Dialect, error) {
+	if h, ok := handle.(dialecter); ok {
+		if d := h.Dialect(); d != nil {
+			return d, nil
+		}
+	}
+
+	h, ok := handle.(driverer)
+	if !ok {
+		d, err := activeDialect()
+		if err != nil {
+			return nil, fmt.Errorf("tql: failed to choose the dialect of %T: %w", handle, err)
+		}
+		return d, nil
+	}
+
+	var detected detection
+	if db, ok := handle.(*sql.DB); ok {
+		detected = detectDBDialect(db)
+	} else {
+		detected = detectDialect(h.Driver())
+	}
+
+	if detected.dialect == nil {
+		d, err := activeDialect()
+		if err != nil {
+			return nil, fmt.Errorf("tql: failed to detect the dialect of driver %T: %w", h.Driver(), err)
+		}
+		return d, nil
+	}
+
+	return detected.dialect, nil
+}
+
+// detectDBDialect
+// Detects the dialect of the driver of db, caching the result until db is garbage collected.
+func detectDBDialect(db *sql.DB) detection {
+	key := weak.Make(db)
+	if cached, found := detectedDialects.Load(key); found {
+		return cached.(detection)
+	}
+
+	detected := detectDialect(db.Driver())
+
+	if _, loaded := detectedDialects.LoadOrStore(key, detected); !loaded {
+		runtime.AddCleanup(db, func(key weak.Pointer[sql.DB]) { detectedDialects.Delete(key) }, key)
+	}
+
+	return detected
+}
+
+// detectDialect
+// Detects the dialect from the package of the driver type, falling back to the dialects
+// registered for the names of the drivers of the same type. The result is cached for the type.
+// Drivers with a Dialect method report their dialect themselves.
+func detectDialect(drv driver.Driver) detection {
+	if drv == nil {
+		return detection{}
+	}
+
+	// Drivers which know their dialect, e.g. the fake drivers used in tests, are not cached
+	// by type, as the drivers of the same type can have different dialects.
+	if d, ok := drv.(dialecter); ok && d.Dialect() != nil {
+		return detection{dialect: d.Dialect()}
+	}
+
+	driverType := reflect.TypeOf(drv)
+	if cached, found := driverTypes.Load(driverType); found {
+		return cached.(detection)
+	}
+
+	detected := detection{dialect: detectDriverType(driverType)}
+	driverTypes.Store(driverType, detected)
+
+	return detected
+}
+
+func detectDriverType(driverType reflect.Type) Dialect {
+	pkg := driverType
+	if pkg.Kind() == reflect.Pointer {
+		pkg = pkg.Elem()
+	}
+
+	if d, found := driverPackages[pkg.PkgPath()]; found {
+		return d
+	}
+
+	for _, driverName := range sql.Drivers() {
+		d, err := dialectFor(driverName)
+		if err != nil {
+			continue
+		}
+
+		// Opening the database does not connect to it, it is only used to get the driver.
+		db, err := sql.Open(driverName, "")
+		if err != nil {
+			continue
+		}
+
+		registered := db.Driver()
+		_ = db.Close()
+
+		if reflect.TypeOf(registered) == driverType {
+			return d
+		}
+	}
+
+	return nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Placeholder(n int) string           { return "$" + strconv.Itoa(n) }
+func (postgresDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, '"') }
+func (postgresDialect) Limit(limit, offset int) string     { return limitOffset(limit, offset, "") }
+
+func (postgresDialect) Supports(feature Feature) bool {
+	return feature == FeatureReturning || feature == FeatureOnConflict
+}
+
+type mySQLDialect struct{}
+
+func (mySQLDialect) Placeholder(int) string             { return "?" }
+func (mySQLDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, '`') }
+
+// Limit
+// MySQL doesn't support OFFSET without LIMIT, so the maximum number of rows is used instead.
+func (mySQLDialect) Limit(limit, offset int) string {
+	return limitOffset(limit, offset, "18446744073709551615")
+}
+
+func (mySQLDialect) Supports(feature Feature) bool {
+	return feature == FeatureLastInsertID
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Placeholder(int) string             { return "?" }
+func (sqliteDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, '"') }
+
+// Limit
+// SQLite doesn't support OFFSET without LIMIT, so a negative limit (no limit) is used instead.
+func (sqliteDialect) Limit(limit, offset int) string { return limitOffset(limit, offset, "-1") }
+
+func (sqliteDialect) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureReturning, FeatureOnConflict, FeatureLastInsertID:
+		return true
+	default:
+		return false
+	}
+}
+
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) Placeholder(n int) string { return "@p" + strconv.Itoa(n) }
+
+func (sqlServerDialect) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// Limit
+// SQL Server doesn't support FETCH without OFFSET, so the rows are offset by 0 instead.
+// Both require the query to be ordered.
+func (sqlServerDialect) Limit(limit, offset int) string {
+	if limit >= 0 && offset < 0 {
+		offset = 0
+	}
+
+	return offsetFetch(limit, offset)
+}
+
+func (sqlServerDialect) Supports(Feature) bool { return false }
+
+type oracleDialect struct{}
+
+func (oracleDialect) Placeholder(n int) string           { return ":" + strconv.Itoa(n) }
+func (oracleDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, '"') }
+func (oracleDialect) Limit(limit, offset int) string     { return offsetFetch(limit, offset) }
+func (oracleDialect) Supports(Feature) bool              { return false }
+
+type duckDBDialect struct{}
+
+func (duckDBDialect) Placeholder(n int) string           { return "$" + strconv.Itoa(n) }
+func (duckDBDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, '"') }
+func (duckDBDialect) Limit(limit, offset int) string     { return limitOffset(limit, offset, "") }
+
+func (duckDBDialect) Supports(feature Feature) bool {
+	return feature == FeatureReturning || feature == FeatureOnConflict
+}
+
+type clickHouseDialect struct{}
+
+func (clickHouseDialect) Placeholder(int) string             { return "?" }
+func (clickHouseDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, '`') }
+
+// Limit
+// ClickHouse doesn't support OFFSET without LIMIT, so the maximum number of rows is used instead.
+func (clickHouseDialect) Limit(limit, offset int) string {
+	return limitOffset(limit, offset, "18446744073709551615")
+}
+
+func (clickHouseDialect) Supports(Feature) bool { return false }
+
+// limitOffset
+// Renders LIMIT ... OFFSET ..., using noLimit as the limit of queries with only an offset
+// for the databases which need one.
+func limitOffset(limit, offset int, noLimit string) string {
+	var clauses []string
+
+	switch {
+	case limit >= 0:
+		clauses = append(clauses, "LIMIT "+strconv.Itoa(limit))
+	case offset >= 0 && noLimit != "":
+		clauses = append(clauses, "LIMIT "+noLimit)
+	}
+
+	if offset >= 0 {
+		clauses = append(clauses, "OFFSET "+strconv.Itoa(offset))
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+// offsetFetch
+// Renders the standard OFFSET ... ROWS FETCH NEXT ... ROWS ONLY.
+func offsetFetch(limit, offset int) string {
+	var clauses []string
+
+	if offset >= 0 {
+		clauses = append(clauses, "OFFSET "+strconv.Itoa(offset)+" ROWS")
+	}
+
+	if limit >= 0 {
+		clauses = append(clauses, "FETCH NEXT "+strconv.Itoa(limit)+" ROWS ONLY")
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+// quoteIdentifier
+// Quotes the name, escaping the quotes inside it by doubling them.
+func quoteIdentifier(name string, quote rune) string {
+	q := string(quote)
+	return q + strings.ReplaceAll(name, q, q+q) + q
+}
@@ -0,0 +1,459 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// questionDialect
+// A dialect of a made up database using ?<n> placeholders.
+type questionDialect struct{ Dialect }
+
+func (questionDialect) Placeholder(n int) string { return "?" + strconv.Itoa(n) }
+
+// questionDriver
+// The driver of the made up database, registered as tql_question.
+type questionDriver struct{ dummyDriver }
+
+// unknownDriver
+// A driver which is not registered with database/sql.
+type unknownDriver struct{ dummyDriver }
+
+type unknownConnector struct{}
+
+func (unknownConnector) Connect(context.Context) (driver.Conn, error) { return nil, driver.ErrBadConn }
+func (unknownConnector) Driver() driver.Driver                        { return unknownDriver{} }
+
+// countingDriver
+// A driver which counts how many times it was opened, registered as tql_counting.
+type countingDriver struct{ dummyDriver }
+
+var countingOpened atomic.Int64
+
+func (countingDriver) OpenConnector(string) (driver.Connector, error) {
+	countingOpened.Add(1)
+	return unknownConnector{}, nil
+}
+
+// undetectedDriver
+// A driver which is neither registered with database/sql nor detected from its package.
+type undetectedDriver struct{ dummyDriver }
+
+type undetectedConnector struct{ unknownConnector }
+
+func (undetectedConnector) Driver() driver.Driver { return undetectedDriver{} }
+
+func Test_CompileQuery_Renders_Placeholders_For_Every_Driver(t *testing.T) {
+	tests := []struct {
+		driverName string
+		expected   string
+	}{
+		{driverName: "postgres", expected: "SELECT * FROM foo WHERE id = $1 AND name = $2;"},
+		{driverName: "pgx", expected: "SELECT * FROM foo WHERE id = $1 AND name = $2;"},
+		{driverName: "cockroach", expected: "SELECT * FROM foo WHERE id = $1 AND name = $2;"},
+		{driverName: "nrpostgres", expected: "SELECT * FROM foo WHERE id = $1 AND name = $2;"},
+		{driverName: "cloudsqlpostgres", expected: "SELECT * FROM foo WHERE id = $1 AND name = $2;"},
+		{driverName: "mysql", expected: "SELECT * FROM foo WHERE id = ? AND name = ?;"},
+		{driverName: "nrmysql", expected: "SELECT * FROM foo WHERE id = ? AND name = ?;"},
+		{driverName: "sqlite3", expected: "SELECT * FROM foo WHERE id = ? AND name = ?;"},
+		{driverName: "nrsqlite3", expected: "SELECT * FROM foo WHERE id = ? AND name = ?;"},
+		{driverName: "sqlite", expected: "SELECT * FROM foo WHERE id = ? AND name = ?;"},
+		{driverName: "sqlserver", expected: "SELECT * FROM foo WHERE id = @p1 AND name = @p2;"},
+		{driverName: "azuresql", expected: "SELECT * FROM foo WHERE id = @p1 AND name = @p2;"},
+		{driverName: "godror", expected: "SELECT * FROM foo WHERE id = :1 AND name = :2;"},
+		{driverName: "oracle", expected: "SELECT * FROM foo WHERE id = :1 AND name = :2;"},
+		{driverName: "duckdb", expected: "SELECT * FROM foo WHERE id = $1 AND name = $2;"},
+		{driverName: "clickhouse", expected: "SELECT * FROM foo WHERE id = ? AND name = ?;"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.driverName, func(t *testing.T) {
+			// Arrange
+			d, err := dialectFor(test.driverName)
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err.Error())
+			}
+
+			// Act
+			compiled := compileQuery(d, "SELECT * FROM foo WHERE id = :id AND name = :name;", false)
+
+			// Assert
+			if compiled.sql != test.expected {
+				t.Fatalf("value '%s' does not equal expected '%s'", compiled.sql, test.expected)
+			}
+		})
+	}
+}
+
+func Test_RegisterDialect_Is_Used_For_Translation(t *testing.T) {
+	// Arrange
+	RegisterDialect("tql_question", questionDialect{Postgres})
+
+	if err := SetActiveDriver("tql_question"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+	defer func() { _ = SetActiveDriver("postgres") }()
+
+	// Act
+	d, err := activeDialect()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	query, args, err := translateParams(d, "SELECT * FROM foo WHERE id = :id;", map[string]any{"id": "1"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT * FROM foo WHERE id = ?1;"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	if len(args) != 1 || args[0] != "1" {
+		t.Fatalf("unexpected args '%v'", args)
+	}
+}
+
+func Test_DialectOf_Detects_Dialect_Of_Registered_Driver(t *testing.T) {
+	// Arrange
+	RegisterDialect("tql_question", questionDialect{Postgres})
+
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, err := sql.Open("tql_question", "")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+	defer func() { _ = db.Close() }()
+
+	// Act
+	d, err := dialectOf(db)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if d != (questionDialect{Postgres}) {
+		t.Fatalf("value '%T' does not equal expected '%T'", d, questionDialect{})
+	}
+}
+
+func Test_DialectOf_Falls_Back_To_Active_Driver(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db := sql.OpenDB(unknownConnector{})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	d, err := dialectOf(db)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if d != Postgres {
+		t.Fatalf("value '%T' does not equal expected '%T'", d, Postgres)
+	}
+}
+
+func Test_DialectOf_Fails_Without_Active_Driver(t *testing.T) {
+	// Arrange
+	previous := activeDriver
+	activeDriver = ""
+	defer func() { activeDriver = previous }()
+
+	db := sql.OpenDB(unknownConnector{})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, err := dialectOf(db)
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_DialectOf_Caches_Failed_Detection(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	RegisterDialect("tql_counting", Postgres)
+	countingOpened.Store(0)
+
+	db := sql.OpenDB(undetectedConnector{})
+	defer func() { _ = db.Close() }()
+
+	other := sql.OpenDB(undetectedConnector{})
+	defer func() { _ = other.Close() }()
+
+	// Act
+	for _, handle := range []*sql.DB{db, db, other} {
+		if _, err := dialectOf(handle); err != nil {
+			t.Fatalf("unexpected err: %s", err.Error())
+		}
+	}
+
+	// Assert
+	if opened := countingOpened.Load(); opened != 1 {
+		t.Fatalf("expected the registered driver to be opened %d times, found %d", 1, opened)
+	}
+}
+
+func Test_WithDialect_Translates_Tx_Queries_With_Multiple_Drivers(t *testing.T) {
+	// Arrange
+	previous := activeDriver
+	activeDriver = ""
+	defer func() { activeDriver = previous }()
+
+	db, c := newFakeDB(fakeResultSet{columns: []string{"id"}, rows: [][]driver.Value{{"1"}}})
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	d, err := DialectOf(db)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	// Act
+	results, err := Query[string](
+		context.Background(),
+		WithDialect(tx, d),
+		"SELECT id FROM foo WHERE id = :id;",
+		map[string]any{"id": "1"},
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT id FROM foo WHERE id = $1;"
+	if c.query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", c.query, expectedQuery)
+	}
+
+	if len(results) != 1 || results[0] != "1" {
+		t.Fatalf("unexpected results '%v'", results)
+	}
+}
+
+func Test_DialectOf_Tx_Fails_With_Multiple_Drivers(t *testing.T) {
+	// Arrange
+	previous := activeDriver
+	activeDriver = ""
+	defer func() { activeDriver = previous }()
+
+	db, _ := newFakeDB()
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Act
+	_, err = dialectOf(tx)
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_DialectFor_Unknown_Driver(t *testing.T) {
+	// Act
+	_, err := dialectFor("unknown")
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_Dialect_Limit(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		limit    int
+		offset   int
+		expected string
+	}{
+		{name: "postgres limit", dialect: Postgres, limit: 10, offset: -1, expected: "LIMIT 10"},
+		{name: "postgres limit offset", dialect: Postgres, limit: 10, offset: 20, expected: "LIMIT 10 OFFSET 20"},
+		{name: "postgres offset", dialect: Postgres, limit: -1, offset: 20, expected: "OFFSET 20"},
+		{name: "postgres none", dialect: Postgres, limit: -1, offset: -1, expected: ""},
+		{name: "mysql offset", dialect: MySQL, limit: -1, offset: 20, expected: "LIMIT 18446744073709551615 OFFSET 20"},
+		{name: "sqlite offset", dialect: SQLite, limit: -1, offset: 20, expected: "LIMIT -1 OFFSET 20"},
+		{name: "sqlite limit", dialect: SQLite, limit: 5, offset: -1, expected: "LIMIT 5"},
+		{name: "sqlserver limit", dialect: SQLServer, limit: 10, offset: -1, expected: "OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{name: "sqlserver limit offset", dialect: SQLServer, limit: 10, offset: 20, expected: "OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{name: "sqlserver offset", dialect: SQLServer, limit: -1, offset: 20, expected: "OFFSET 20 ROWS"},
+		{name: "sqlserver none", dialect: SQLServer, limit: -1, offset: -1, expected: ""},
+		{name: "oracle limit", dialect: Oracle, limit: 10, offset: -1, expected: "FETCH NEXT 10 ROWS ONLY"},
+		{name: "oracle limit offset", dialect: Oracle, limit: 10, offset: 20, expected: "OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{name: "duckdb offset", dialect: DuckDB, limit: -1, offset: 20, expected: "OFFSET 20"},
+		{name: "clickhouse offset", dialect: ClickHouse, limit: -1, offset: 20, expected: "LIMIT 18446744073709551615 OFFSET 20"},
+		{name: "clickhouse limit offset", dialect: ClickHouse, limit: 10, offset: 20, expected: "LIMIT 10 OFFSET 20"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			clause := test.dialect.Limit(test.limit, test.offset)
+
+			// Assert
+			if clause != test.expected {
+				t.Fatalf("value '%s' does not equal expected '%s'", clause, test.expected)
+			}
+		})
+	}
+}
+
+func Test_Dialect_QuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		expected string
+	}{
+		{name: "postgres", dialect: Postgres, expected: `"my""table"`},
+		{name: "mysql", dialect: MySQL, expected: "`my\"table`"},
+		{name: "sqlite", dialect: SQLite, expected: `"my""table"`},
+		{name: "sqlserver", dialect: SQLServer, expected: `[my"table]`},
+		{name: "oracle", dialect: Oracle, expected: `"my""table"`},
+		{name: "duckdb", dialect: DuckDB, expected: `"my""table"`},
+		{name: "clickhouse", dialect: ClickHouse, expected: "`my\"table`"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			quoted := test.dialect.QuoteIdentifier(`my"table`)
+
+			// Assert
+			if quoted != test.expected {
+				t.Fatalf("value '%s' does not equal expected '%s'", quoted, test.expected)
+			}
+		})
+	}
+}
+
+func Test_Dialect_Supports(t *testing.T) {
+	// Assert
+	if !Postgres.Supports(FeatureReturning) || Postgres.Supports(FeatureLastInsertID) {
+		t.Fatalf("unexpected postgres features")
+	}
+
+	if MySQL.Supports(FeatureReturning) || !MySQL.Supports(FeatureLastInsertID) {
+		t.Fatalf("unexpected mysql features")
+	}
+
+	if !SQLite.Supports(FeatureReturning) || !SQLite.Supports(FeatureOnConflict) {
+		t.Fatalf("unexpected sqlite features")
+	}
+
+	if !DuckDB.Supports(FeatureReturning) || DuckDB.Supports(FeatureLastInsertID) {
+		t.Fatalf("unexpected duckdb features")
+	}
+
+	for _, d := range []Dialect{SQLServer, Oracle, ClickHouse} {
+		if d.Supports(FeatureReturning) || d.Supports(FeatureOnConflict) || d.Supports(FeatureLastInsertID) {
+			t.Fatalf("unexpected %T features", d)
+		}
+	}
+}
+
+func Test_SQLServer_Quotes_Closing_Brackets(t *testing.T) {
+	// Act
+	quoted := SQLServer.QuoteIdentifier("my]table")
+
+	// Assert
+	const expected = "[my]]table]"
+	if quoted != expected {
+		t.Fatalf("value '%s' does not equal expected '%s'", quoted, expected)
+	}
+}
+
+func Test_Oracle_Keeps_Positional_Parameters(t *testing.T) {
+	// Act
+	query, args, err := translateParams(Oracle, "SELECT * FROM foo WHERE id = :1 AND name = :2;", "1", "foo")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT * FROM foo WHERE id = :1 AND name = :2;"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	if len(args) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(args))
+	}
+}
+
+func Test_Oracle_Mixed_Parameters(t *testing.T) {
+	// Act
+	_, _, err := translateParams(Oracle, "SELECT * FROM foo WHERE id = :1 AND name = :name;", map[string]any{"name": "foo"})
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_SQLServer_Named_Parameters_With_Variables(t *testing.T) {
+	// Act
+	query, args, err := translateParams(
+		SQLServer,
+		"UPDATE foo SET value = :value WHERE id = :id; SELECT @@ROWCOUNT;",
+		map[string]any{"id": "1", "value": "foo"},
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "UPDATE foo SET value = @p1 WHERE id = @p2; SELECT @@ROWCOUNT;"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	if len(args) != 2 || args[0] != "foo" || args[1] != "1" {
+		t.Fatalf("unexpected args '%v'", args)
+	}
+}
+
+func Test_SQLServer_Mixed_Parameters(t *testing.T) {
+	// Act
+	_, _, err := translateParams(SQLServer, "SELECT * FROM foo WHERE id = @p1 AND name = :name;", map[string]any{"name": "foo"})
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
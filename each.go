@@ -0,0 +1,142 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// QueryEach
+// Queries the database and calls fn with every row mapped to T, using the same mapping as tql.Query,
+// without collecting the rows into a slice. If fn returns an error, the iteration stops and the error
+// is returned.
+//
+// The destinations of the row are reused for every row, so the fields of type sql.RawBytes are not
+// copied. They point into the memory of the driver and are valid *only* until fn returns.
+// Types with 'many' fields can't be streamed, as their rows have to be grouped.
+func QueryEach[T any](ctx context.Context, q Querier, query string, fn func(T) error, params ...any) error {
+	agg, err := typeAggregation(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+
+	if agg != nil {
+		return fmt.Errorf("invalid type %s: types with 'many' fields can't be streamed", reflect.TypeFor[T]())
+	}
+
+	return queryRows(ctx, q, query, params, func(rows *sql.Rows) error {
+		var (
+			current T
+			zero    T
+			scanner *structScanner
+		)
+
+		if structType, pointer, mapped := mappedStruct(reflect.TypeFor[T]()); mapped {
+			if scanner, err = newStructScanner(rows, structType, pointer); err != nil {
+				return err
+			}
+		}
+
+		scan := func() error {
+			if scanner != nil {
+				return scanner.scan(rows, reflect.ValueOf(&current).Elem())
+			}
+			return scanRow(rows, &current)
+		}
+
+		for rows.Next() {
+			current = zero
+
+			if err := scan(); err != nil {
+				return err
+			}
+
+			if err := fn(current); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RawRow
+// A row passed to the callback of tql.QueryRaw. The values are in the order of the columns,
+// and are nil for NULL columns.
+//
+// The values point into the memory of the driver and are valid *only* until the callback returns.
+// They are overwritten by the next row, so they *must* be copied to be retained.
+type RawRow struct {
+	Columns []string
+	Values  []sql.RawBytes
+}
+
+// QueryRaw
+// Queries the database and calls fn with every row, without copying the values of the columns.
+// Meant for the rows which are transformed and discarded immediately, e.g. when exporting large
+// text and blob columns. If fn returns an error, the iteration stops and the error is returned.
+//
+// Parameters are translated in the same way as in tql.Query.
+func QueryRaw(ctx context.Context, q Querier, query string, fn func(RawRow) error, params ...any) error {
+	return queryRows(ctx, q, query, params, func(rows *sql.Rows) error {
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+
+		row := RawRow{Columns: cols, Values: make([]sql.RawBytes, len(cols))}
+
+		dest := make([]any, len(cols))
+		for i := range row.Values {
+			dest[i] = &row.Values[i]
+		}
+
+		for rows.Next() {
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// queryRows
+// Translates the parameters and queries the database, passing the rows to read and closing them
+// once read returns.
+func queryRows(ctx context.Context, q Querier, query string, params []any, read func(*sql.Rows) error) error {
+	d, err := dialectOf(q)
+	if err != nil {
+		return err
+	}
+
+	parameterisedQuery, args, err := translateParams(d, query, params...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.QueryContext(ctx, parameterisedQuery, args...)
+	if err != nil {
+		return err
+	}
+
+	if rows == nil {
+		return nil
+	}
+
+	if err := read(rows); err != nil {
+		return errors.Join(err, rows.Close())
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.Join(err, rows.Close())
+	}
+
+	return rows.Close()
+}
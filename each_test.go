@@ -0,0 +1,165 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func eachDB() *sql.DB {
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "data"},
+		rows: [][]driver.Value{
+			{"1", []byte("foo")},
+			{"2", nil},
+			{"3", []byte("baz")},
+		},
+	})
+
+	return db
+}
+
+func Test_QueryEach_Streams_Rows(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	type raw struct {
+		ID   string       `db:"id"`
+		Data sql.RawBytes `db:"data"`
+	}
+
+	db := eachDB()
+	defer func() { _ = db.Close() }()
+
+	var (
+		ids  []string
+		data []string
+	)
+
+	// Act
+	err := QueryEach(context.Background(), db, "SELECT id, data FROM foo;", func(r raw) error {
+		ids = append(ids, r.ID)
+		data = append(data, string(r.Data))
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(ids, []string{"1", "2", "3"}) {
+		t.Fatalf("value '%v' does not equal expected '%v'", ids, []string{"1", "2", "3"})
+	}
+
+	if !reflect.DeepEqual(data, []string{"foo", "", "baz"}) {
+		t.Fatalf("value '%v' does not equal expected '%v'", data, []string{"foo", "", "baz"})
+	}
+}
+
+func Test_QueryEach_Stops_On_Callback_Error(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db := eachDB()
+	defer func() { _ = db.Close() }()
+
+	errStop := errors.New("stop")
+	calls := 0
+
+	// Act
+	err := QueryEach(context.Background(), db, "SELECT id, data FROM foo;", func(r struct {
+		ID   string `db:"id"`
+		Data []byte `db:"data"`
+	}) error {
+		calls++
+		return errStop
+	})
+
+	// Assert
+	if !errors.Is(err, errStop) {
+		t.Fatalf("value '%v' does not equal expected '%v'", err, errStop)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected %d calls found %d", 1, calls)
+	}
+}
+
+func Test_QueryEach_Rejects_Aggregated_Types(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	type child struct {
+		ID string `db:"child_id,pk"`
+	}
+
+	type parent struct {
+		ID       string  `db:"id,pk"`
+		Children []child `db:"children,many"`
+	}
+
+	db := eachDB()
+	defer func() { _ = db.Close() }()
+
+	// Act
+	err := QueryEach(context.Background(), db, "SELECT id FROM foo;", func(parent) error { return nil })
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_QueryRaw_Passes_Raw_Values(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db := eachDB()
+	defer func() { _ = db.Close() }()
+
+	var (
+		columns []string
+		values  [][]string
+		nulls   int
+	)
+
+	// Act
+	err := QueryRaw(context.Background(), db, "SELECT id, data FROM foo WHERE id = :id;", func(row RawRow) error {
+		columns = row.Columns
+		values = append(values, []string{string(row.Values[0]), string(row.Values[1])})
+		if row.Values[1] == nil {
+			nulls++
+		}
+		return nil
+	}, map[string]any{"id": "1"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(columns, []string{"id", "data"}) {
+		t.Fatalf("value '%v' does not equal expected '%v'", columns, []string{"id", "data"})
+	}
+
+	expected := [][]string{{"1", "foo"}, {"2", ""}, {"3", "baz"}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", values, expected)
+	}
+
+	if nulls != 1 {
+		t.Fatalf("expected %d nulls found %d", 1, nulls)
+	}
+}
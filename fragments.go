@@ -0,0 +1,242 @@
+package tql
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Optional query fragments
+//
+// A fragment is included in the query only if all the named parameters listed in its header
+// are present, e.g.
+//
+//	SELECT * FROM foo
+//	WHERE /*? :name */ name = :name /* end */
+//	/*? :min_age :max_age */ AND age BETWEEN :min_age AND :max_age /* end */
+//	ORDER BY id;
+//
+// A parameter is missing if it is not in the provided struct or map, or if it is nil or
+// the zero value of its type. Non-nil pointers are always present, so pointers can be used
+// to pass zero values into fragments. Fragments can be nested.
+//
+// After the excluded fragments are removed, the AND/OR keywords left dangling at the start or
+// the end of a condition are removed, as well as the parentheses and the WHERE and HAVING clauses
+// left without conditions. The text inside string literals and quoted identifiers is left as is.
+var (
+	fragmentStart = regexp.MustCompile(`/\*\?([^*]*)\*/`)
+	fragmentEnd   = regexp.MustCompile(`/\*\s*end\s*\*/`)
+)
+
+var (
+	// A condition keyword following the start of a clause or another condition keyword.
+	danglingLeadingOperator = regexp.MustCompile(`(?i)(\bWHERE|\bHAVING|\bAND|\bOR|\()\s+(AND|OR)\b\s*`)
+
+	// A condition keyword followed by the end of the query, a closing parenthesis or the next clause.
+	danglingTrailingOperator = regexp.MustCompile(
+		`(?i)\s+\b(AND|OR)\s*(\)|;|$|\b(ORDER|GROUP|LIMIT|OFFSET|HAVING|UNION|RETURNING|FOR)\b)`,
+	)
+
+	// Parentheses without any conditions, following the start of a clause or a condition keyword,
+	// so the parentheses of function calls are kept.
+	emptyParentheses = regexp.MustCompile(`(?i)(\bWHERE|\bHAVING|\bAND|\bOR|\()\s*\(\s*\)`)
+
+	// A clause without any conditions.
+	emptyClause = regexp.MustCompile(
+		`(?i)\s+\b(WHERE|HAVING)\s*(\)|;|$|\b(ORDER|GROUP|LIMIT|OFFSET|HAVING|UNION|RETURNING|FOR)\b)`,
+	)
+)
+
+// expandFragments
+// Includes or removes the optional fragments of the query depending on the provided parameters,
+// and removes the fragment markers.
+func expandFragments(query string, parameters map[string]any) (string, error) {
+	if !hasFragments(query) {
+		return query, nil
+	}
+
+	var (
+		result   strings.Builder
+		included []bool
+		removed  bool
+	)
+
+	result.Grow(len(query))
+
+	isIncluded := func() bool {
+		for _, i := range included {
+			if !i {
+				return false
+			}
+		}
+		return true
+	}
+
+	rest := query
+	for {
+		start := fragmentStart.FindStringSubmatchIndex(rest)
+		end := fragmentEnd.FindStringIndex(rest)
+
+		if start == nil && end == nil {
+			if isIncluded() {
+				result.WriteString(rest)
+			}
+			break
+		}
+
+		if start != nil && (end == nil || start[0] < end[0]) {
+			if isIncluded() {
+				result.WriteString(rest[:start[0]])
+			}
+
+			include, err := fragmentIncluded(rest[start[2]:start[3]], parameters)
+			if err != nil {
+				return "", err
+			}
+
+			removed = removed || !include
+			included = append(included, include)
+
+			rest = rest[start[1]:]
+			continue
+		}
+
+		if len(included) == 0 {
+			return "", fmt.Errorf("found query fragment end without a matching start")
+		}
+
+		if isIncluded() {
+			result.WriteString(rest[:end[0]])
+		}
+
+		included = included[:len(included)-1]
+		rest = rest[end[1]:]
+	}
+
+	if len(included) > 0 {
+		return "", fmt.Errorf("found query fragment start without a matching end")
+	}
+
+	if !removed {
+		return result.String(), nil
+	}
+
+	return cleanupConditions(result.String()), nil
+}
+
+// hasFragments
+// Reports whether the query contains the markers of optional fragments.
+func hasFragments(query string) bool {
+	return fragmentStart.MatchString(query) || fragmentEnd.MatchString(query)
+}
+
+func fragmentIncluded(header string, parameters map[string]any) (bool, error) {
+	names := strings.Fields(header)
+	if len(names) == 0 {
+		return false, fmt.Errorf("query fragment does not specify any parameters")
+	}
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, ":") || len(name) < 2 {
+			return false, fmt.Errorf("invalid query fragment parameter: %s", name)
+		}
+
+		value, found := parameters[name[1:]]
+		if !found || value == nil {
+			return false, nil
+		}
+
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Pointer && v.IsZero() {
+			return false, nil
+		}
+
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func cleanupConditions(query string) string {
+	query, quoted := maskQuoted(query)
+
+	for {
+		cleaned := danglingLeadingOperator.ReplaceAllString(query, "$1 ")
+		cleaned = danglingTrailingOperator.ReplaceAllString(cleaned, " $2")
+		cleaned = emptyParentheses.ReplaceAllString(cleaned, "$1 ")
+		cleaned = emptyClause.ReplaceAllString(cleaned, " $2")
+
+		if cleaned == query {
+			return unmaskQuoted(query, quoted)
+		}
+		query = cleaned
+	}
+}
+
+// maskQuoted
+// Removes the text inside the string literals and quoted identifiers of the query, so the cleanup
+// doesn't match the keywords inside them. Returns the removed text of every quote, in order.
+func maskQuoted(query string) (string, []string) {
+	var (
+		result strings.Builder
+		quoted []string
+	)
+
+	result.Grow(len(query))
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c != '\'' && c != '"' && c != '`' {
+			result.WriteByte(c)
+			continue
+		}
+
+		// An unterminated quote lasts until the end of the query.
+		end := strings.IndexByte(query[i+1:], c)
+		if end < 0 {
+			end = len(query) - i - 1
+		}
+
+		quoted = append(quoted, query[i+1:i+1+end])
+
+		result.WriteByte(c)
+		if i+1+end < len(query) {
+			result.WriteByte(c)
+		}
+
+		i += end + 1
+	}
+
+	return result.String(), quoted
+}
+
+// unmaskQuoted
+// Puts the text removed by maskQuoted back into the quotes, in order. The cleanup never removes
+// the quotes, so they are found in the same order.
+func unmaskQuoted(query string, quoted []string) string {
+	var result strings.Builder
+	result.Grow(len(query))
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if (c != '\'' && c != '"' && c != '`') || len(quoted) == 0 {
+			result.WriteByte(c)
+			continue
+		}
+
+		result.WriteByte(c)
+		result.WriteString(quoted[0])
+		quoted = quoted[1:]
+
+		// The closing quote directly follows the opening one, unless the quote was unterminated.
+		if i+1 < len(query) {
+			result.WriteByte(query[i+1])
+			i++
+		}
+	}
+
+	return result.String()
+}
@@ -0,0 +1,155 @@
+package tql
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Postgres_TranslateParams_Optional_Fragments(t *testing.T) {
+	// Arrange
+	err := SetActiveDriver("postgres")
+	if err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	const query = "SELECT * FROM foo WHERE /*? :name */ name = :name /* end */ " +
+		"/*? :min :max */ AND age BETWEEN :min AND :max /* end */ " +
+		"/*? :deleted */ OR deleted = :deleted /* end */ ORDER BY id;"
+
+	type params struct {
+		Name    string `db:"name"`
+		Min     int    `db:"min"`
+		Max     int    `db:"max"`
+		Deleted *bool  `db:"deleted"`
+	}
+
+	deleted := false
+
+	tests := []struct {
+		name         string
+		params       params
+		expected     string
+		expectedArgs int
+	}{
+		{
+			name:         "all present",
+			params:       params{Name: "foo", Min: 1, Max: 2, Deleted: &deleted},
+			expected:     "SELECT * FROM foo WHERE name = $1 AND age BETWEEN $2 AND $3 OR deleted = $4 ORDER BY id;",
+			expectedArgs: 4,
+		},
+		{
+			name:         "leading missing",
+			params:       params{Min: 1, Max: 2},
+			expected:     "SELECT * FROM foo WHERE age BETWEEN $1 AND $2 ORDER BY id;",
+			expectedArgs: 2,
+		},
+		{
+			name:         "partially missing header",
+			params:       params{Name: "foo", Min: 1},
+			expected:     "SELECT * FROM foo WHERE name = $1 ORDER BY id;",
+			expectedArgs: 1,
+		},
+		{
+			name:         "all missing",
+			params:       params{},
+			expected:     "SELECT * FROM foo ORDER BY id;",
+			expectedArgs: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			parameterisedQuery, args, err := translateParams(Postgres, query, test.params)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err.Error())
+			}
+
+			// The removed fragments leave their surrounding whitespace behind.
+			parameterisedQuery = strings.Join(strings.Fields(parameterisedQuery), " ")
+			if parameterisedQuery != test.expected {
+				t.Fatalf("value '%s' does not equal expected '%s'", parameterisedQuery, test.expected)
+			}
+
+			if len(args) != test.expectedArgs {
+				t.Fatalf("expected len %d found %d", test.expectedArgs, len(args))
+			}
+		})
+	}
+}
+
+func Test_TranslateParams_Unterminated_Fragment(t *testing.T) {
+	// Arrange
+	err := SetActiveDriver("postgres")
+	if err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	// Act
+	_, _, err = translateParams(Postgres, "SELECT * FROM foo WHERE /*? :name */ name = :name", map[string]any{})
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_ExpandFragments_Cleanup(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "empty parentheses",
+			query:    "SELECT * FROM foo WHERE (/*? :a */ a = :a /* end */);",
+			expected: "SELECT * FROM foo ;",
+		},
+		{
+			name: "empty nested parentheses",
+			query: "SELECT * FROM foo WHERE b = :b AND ((/*? :a */ a = :a /* end */) " +
+				"OR /*? :c */ c = :c /* end */) ORDER BY id;",
+			expected: "SELECT * FROM foo WHERE b = :b ORDER BY id;",
+		},
+		{
+			name:     "function call",
+			query:    "SELECT * FROM foo WHERE created_at < now() /*? :a */ AND a = :a /* end */;",
+			expected: "SELECT * FROM foo WHERE created_at < now() ;",
+		},
+		{
+			name:     "string literal",
+			query:    "SELECT * FROM foo WHERE note = 'WHERE ORDER' /*? :a */ AND a = :a /* end */;",
+			expected: "SELECT * FROM foo WHERE note = 'WHERE ORDER' ;",
+		},
+		{
+			name:     "escaped quote",
+			query:    "SELECT * FROM foo WHERE note <> 'it''s OR )' /*? :a */ AND a = :a /* end */;",
+			expected: "SELECT * FROM foo WHERE note <> 'it''s OR )' ;",
+		},
+		{
+			name:     "quoted identifier",
+			query:    `SELECT "AND ()" FROM foo WHERE /*? :a */ a = :a /* end */;`,
+			expected: `SELECT "AND ()" FROM foo ;`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			query, err := expandFragments(test.query, map[string]any{"b": 1})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err.Error())
+			}
+
+			// The removed fragments leave their surrounding whitespace behind.
+			query = strings.Join(strings.Fields(query), " ")
+			if query != test.expected {
+				t.Fatalf("value '%s' does not equal expected '%s'", query, test.expected)
+			}
+		})
+	}
+}
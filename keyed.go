@@ -0,0 +1,114 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrDuplicateKey = errors.New("sql: found multiple results with the same key")
+
+// QueryMap
+// Queries the database and returns the results keyed by the first column. The remaining columns are
+// mapped to V in the same way as in tql.Query, e.g.
+//
+//	users, err := tql.QueryMap[string, User](ctx, db, "SELECT id, id, name FROM users;")
+//
+// The key column is not mapped to V, so it has to be selected twice to be in both.
+// If multiple rows have the same key, this function returns tql.ErrDuplicateKey. If the query
+// returns no results, an empty map is returned.
+func QueryMap[K comparable, V any](ctx context.Context, q Querier, query string, params ...any) (map[K]V, error) {
+	result := make(map[K]V)
+
+	err := queryKeyed(ctx, q, query, params, func(k K, v V) error {
+		if _, found := result[k]; found {
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, k)
+		}
+
+		result[k] = v
+		return nil
+	})
+
+	return result, err
+}
+
+// QueryGroup
+// Queries the database and returns the results grouped by the first column, in the order in which
+// they were returned. The remaining columns are mapped to V in the same way as in tql.QueryMap.
+// If the query returns no results, an empty map is returned.
+func QueryGroup[K comparable, V any](ctx context.Context, q Querier, query string, params ...any) (map[K][]V, error) {
+	result := make(map[K][]V)
+
+	err := queryKeyed(ctx, q, query, params, func(k K, v V) error {
+		result[k] = append(result[k], v)
+		return nil
+	})
+
+	return result, err
+}
+
+// queryKeyed
+// Queries the database and calls fn with the first column of every row as the key, and the rest
+// of the columns mapped to V.
+func queryKeyed[K comparable, V any](ctx context.Context, q Querier, query string, params []any, fn func(K, V) error) error {
+	agg, err := typeAggregation(reflect.TypeFor[V]())
+	if err != nil {
+		return err
+	}
+
+	if agg != nil {
+		return fmt.Errorf("invalid type %s: types with 'many' fields can't be keyed", reflect.TypeFor[V]())
+	}
+
+	return queryRows(ctx, q, query, params, func(rows *sql.Rows) error {
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+
+		if len(cols) < 2 {
+			return fmt.Errorf("expected a key column and at least one value column, query returned %d columns", len(cols))
+		}
+
+		var (
+			key     K
+			value   V
+			scanner *structScanner
+		)
+
+		structType, pointer, mapped := mappedStruct(reflect.TypeFor[V]())
+		if mapped {
+			if scanner, err = structScannerFor(cols[1:], structType, pointer); err != nil {
+				return err
+			}
+		} else if len(cols) != 2 {
+			return fmt.Errorf("expected %d columns, query returned %d columns", 2, len(cols))
+		}
+
+		dest := make([]any, len(cols))
+		dest[0] = &key
+
+		for rows.Next() {
+			var zero V
+			value = zero
+
+			if scanner != nil {
+				copy(dest[1:], scanner.bind(reflect.ValueOf(&value).Elem()))
+			} else {
+				dest[1] = &value
+			}
+
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+
+			if err := fn(key, value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
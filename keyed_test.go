@@ -0,0 +1,187 @@
+package tql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type keyedUser struct {
+	ID   string `db:"id"`
+	Name string `db:"name"`
+}
+
+func Test_QueryMap_Struct_Values(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"key", "id", "name"},
+		rows:    [][]driver.Value{{"1", "1", "foo"}, {"2", "2", "bar"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	users, err := QueryMap[string, keyedUser](context.Background(), db, "SELECT id AS key, id, name FROM users;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expected := map[string]keyedUser{"1": {ID: "1", Name: "foo"}, "2": {ID: "2", Name: "bar"}}
+	if !reflect.DeepEqual(users, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", users, expected)
+	}
+}
+
+func Test_QueryMap_Struct_Pointer_Values(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"key", "name"},
+		rows:    [][]driver.Value{{int64(1), "foo"}, {int64(2), "bar"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	users, err := QueryMap[int, *keyedUser](context.Background(), db, "SELECT id, name FROM users;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(users) != 2 || users[1].Name != "foo" || users[2].Name != "bar" || users[1] == users[2] {
+		t.Fatalf("unexpected values '%v'", users)
+	}
+}
+
+func Test_QueryMap_Scalar_Values(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{"1", "foo"}, {"2", nil}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	names, err := QueryMap[string, *string](context.Background(), db, "SELECT id, name FROM users;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(names) != 2 || *names["1"] != "foo" || names["2"] != nil {
+		t.Fatalf("unexpected values '%v'", names)
+	}
+}
+
+func Test_QueryMap_Duplicate_Key(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{"1", "foo"}, {"1", "bar"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, err := QueryMap[string, string](context.Background(), db, "SELECT id, name FROM users;")
+
+	// Assert
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("value '%v' does not equal expected '%v'", err, ErrDuplicateKey)
+	}
+}
+
+func Test_QueryMap_Scalar_Values_Column_Count_Mismatch(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name", "value"},
+		rows:    [][]driver.Value{{"1", "foo", "bar"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, err := QueryMap[string, string](context.Background(), db, "SELECT id, name, value FROM users;")
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_QueryGroup_Groups_Values(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"team", "id", "name"},
+		rows: [][]driver.Value{
+			{"a", "1", "foo"},
+			{"b", "2", "bar"},
+			{"a", "3", "baz"},
+		},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	teams, err := QueryGroup[string, keyedUser](context.Background(), db, "SELECT team, id, name FROM users;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expected := map[string][]keyedUser{
+		"a": {{ID: "1", Name: "foo"}, {ID: "3", Name: "baz"}},
+		"b": {{ID: "2", Name: "bar"}},
+	}
+	if !reflect.DeepEqual(teams, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", teams, expected)
+	}
+}
+
+func Test_QueryGroup_Empty_Result(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{columns: []string{"team", "name"}})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	teams, err := QueryGroup[string, string](context.Background(), db, "SELECT team, name FROM users;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if teams == nil || len(teams) != 0 {
+		t.Fatalf("expected empty map, found '%v'", teams)
+	}
+}
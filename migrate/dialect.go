@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Dialect
+// The database the migrations are run against.
+type Dialect string
+
+const (
+	Postgres    Dialect = "postgres"
+	CockroachDB Dialect = "cockroachdb"
+	// MySQL is used for both MySQL and MariaDB. The connection needs to allow multiple statements
+	// (multiStatements=true with github.com/go-sql-driver/mysql) for scripts containing more than one statement.
+	MySQL  Dialect = "mysql"
+	SQLite Dialect = "sqlite"
+)
+
+func (d Dialect) validate() error {
+	switch d {
+	case Postgres, CockroachDB, MySQL, SQLite:
+		return nil
+	default:
+		return fmt.Errorf("unsupported migration dialect: %s", d)
+	}
+}
+
+// placeholder
+// Returns the n-th (1-based) positional parameter of the dialect.
+func (d Dialect) placeholder(n int) string {
+	switch d {
+	case Postgres, CockroachDB:
+		return "$" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+func (d Dialect) createSchemaTable() string {
+	switch d {
+	case MySQL:
+		return `
+			CREATE TABLE IF NOT EXISTS schema_migration (
+				id integer AUTO_INCREMENT PRIMARY KEY,
+				name varchar(255) NOT NULL,
+				version integer NOT NULL,
+				checksum varchar(64)
+			)`
+	case SQLite:
+		return `
+			CREATE TABLE IF NOT EXISTS schema_migration (
+				id integer PRIMARY KEY AUTOINCREMENT,
+				name text NOT NULL,
+				version integer NOT NULL,
+				checksum text
+			)`
+	default:
+		return `
+			CREATE TABLE IF NOT EXISTS schema_migration (
+				id serial PRIMARY KEY,
+				name text NOT NULL,
+				version integer NOT NULL,
+				checksum text
+			)`
+	}
+}
+
+func (d Dialect) createRepeatableSchemaTable() string {
+	if d == MySQL {
+		return `
+			CREATE TABLE IF NOT EXISTS schema_repeatable_migration (
+				name varchar(255) PRIMARY KEY,
+				checksum varchar(64) NOT NULL
+			)`
+	}
+	return `
+		CREATE TABLE IF NOT EXISTS schema_repeatable_migration (
+			name text PRIMARY KEY,
+			checksum text NOT NULL
+		)`
+}
+
+// addChecksumColumn
+// Adds the checksum column to the schema_migration tables created before checksums were stored.
+func (d Dialect) addChecksumColumn() string {
+	if d == MySQL {
+		return "ALTER TABLE schema_migration ADD COLUMN checksum varchar(64);"
+	}
+	return "ALTER TABLE schema_migration ADD COLUMN checksum text;"
+}
@@ -0,0 +1,241 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	defaultLockTimeout      = 5 * time.Minute
+	defaultStaleLockTimeout = time.Hour
+)
+
+const (
+	// lockKey identifies the advisory lock on Postgres.
+	lockKey int64 = 7_463_734_161_696_421
+
+	// lockName identifies the named lock on MySQL/MariaDB.
+	lockName = "tql_schema_migration"
+
+	// lockPollInterval is how often the lock row is retried on SQLite.
+	lockPollInterval = 100 * time.Millisecond
+)
+
+var ErrLockTimeout = errors.New("migrate: timed out waiting for the migration lock")
+
+// locked
+// Runs f on a dedicated connection while holding the migration lock. The lock is released
+// once f returns, regardless of whether it failed.
+//
+// The lock depends on the dialect:
+//   - Postgres: session level advisory lock (pg_advisory_lock).
+//   - MySQL/MariaDB: named lock (GET_LOCK).
+//   - CockroachDB: row lock (SELECT ... FOR UPDATE) on the schema_migration_lock table,
+//     held in a transaction on a separate connection.
+//   - SQLite: a row in the schema_migration_lock table. If the process holding it dies,
+//     the row is taken over once it is older than the stale lock timeout.
+func (m *Migrator) locked(ctx context.Context, f func(c *sql.Conn) error) (err error) {
+	c, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// The connection is already closed if it was discarded while taking the lock.
+		if closeErr := c.Close(); closeErr != nil && !errors.Is(closeErr, sql.ErrConnDone) {
+			err = errors.Join(err, closeErr)
+		}
+	}()
+
+	unlock, err := m.lock(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// The context might already be cancelled, but the lock needs to be released regardless.
+		if unlockErr := unlock(context.WithoutCancel(ctx)); unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release the migration lock: %w", unlockErr))
+		}
+	}()
+
+	return f(c)
+}
+
+func (m *Migrator) lock(ctx context.Context, c *sql.Conn) (func(context.Context) error, error) {
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+
+	var (
+		unlock func(context.Context) error
+		err    error
+	)
+
+	switch m.dialect {
+	case Postgres:
+		unlock, err = lockPostgres(lockCtx, c)
+	case MySQL:
+		unlock, err = lockMySQL(lockCtx, c, m.lockTimeout)
+	case CockroachDB:
+		unlock, err = lockCockroachDB(ctx, lockCtx, m.db, c)
+	case SQLite:
+		unlock, err = lockSQLite(lockCtx, c, m.staleLock)
+	default:
+		err = fmt.Errorf("unsupported migration dialect: %s", m.dialect)
+	}
+
+	if err != nil && errors.Is(lockCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, errors.Join(ErrLockTimeout, err)
+	}
+
+	return unlock, err
+}
+
+func lockPostgres(ctx context.Context, c *sql.Conn) (func(context.Context) error, error) {
+	if _, err := c.ExecContext(ctx, "SELECT pg_advisory_lock($1);", lockKey); err != nil {
+		// The lock might have been taken just as the wait was cancelled. The session lock is only released
+		// with the session, so the connection is discarded instead of being returned to the pool.
+		if ctx.Err() != nil {
+			discard(c)
+		}
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		_, err := c.ExecContext(ctx, "SELECT pg_advisory_unlock($1);", lockKey)
+		return err
+	}, nil
+}
+
+func lockMySQL(ctx context.Context, c *sql.Conn, timeout time.Duration) (func(context.Context) error, error) {
+	// GET_LOCK returns 1 if the lock was acquired, 0 if it timed out and NULL on error.
+	var acquired sql.NullInt64
+	err := c.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?);", lockName, lockWaitSeconds(timeout)).Scan(&acquired)
+	if err != nil {
+		return nil, err
+	}
+
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return nil, ErrLockTimeout
+	}
+
+	return func(ctx context.Context) error {
+		_, err := c.ExecContext(ctx, "SELECT RELEASE_LOCK(?);", lockName)
+		return err
+	}, nil
+}
+
+// lockWaitSeconds
+// Returns the timeout in whole seconds for GET_LOCK, rounded up so timeouts under a second
+// still wait instead of failing at once.
+func lockWaitSeconds(timeout time.Duration) int {
+	return max(int(math.Ceil(timeout.Seconds())), 1)
+}
+
+func lockCockroachDB(
+	ctx context.Context,
+	lockCtx context.Context,
+	db *sql.DB,
+	c *sql.Conn,
+) (func(context.Context) error, error) {
+	const createLockTable = `
+		CREATE TABLE IF NOT EXISTS schema_migration_lock (
+			id integer PRIMARY KEY
+		);`
+	if _, err := c.ExecContext(lockCtx, createLockTable); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.ExecContext(lockCtx, "INSERT INTO schema_migration_lock (id) VALUES (1) ON CONFLICT DO NOTHING;"); err != nil {
+		return nil, err
+	}
+
+	// The row lock is held by a transaction on a connection of its own, so the migrations
+	// can still run in transactions of their own. The transaction is bound to ctx rather than lockCtx,
+	// as database/sql rolls it back as soon as its context is done.
+	lockConn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := lockConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Join(err, lockConn.Close())
+	}
+
+	if _, err := tx.ExecContext(lockCtx, "SELECT id FROM schema_migration_lock WHERE id = 1 FOR UPDATE;"); err != nil {
+		return nil, errors.Join(err, tx.Rollback(), lockConn.Close())
+	}
+
+	return func(context.Context) error {
+		return errors.Join(tx.Rollback(), lockConn.Close())
+	}, nil
+}
+
+func lockSQLite(ctx context.Context, c *sql.Conn, staleAfter time.Duration) (func(context.Context) error, error) {
+	const createLockTable = `
+		CREATE TABLE IF NOT EXISTS schema_migration_lock (
+			id integer PRIMARY KEY,
+			locked_at text NOT NULL
+		);`
+	if _, err := c.ExecContext(ctx, createLockTable); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+
+		// The timestamps are stored in UTC with the same format, so they compare as text.
+		// The row left behind by an instance which died is deleted once it is stale.
+		if _, err := c.ExecContext(
+			ctx,
+			"DELETE FROM schema_migration_lock WHERE id = 1 AND locked_at < ?;",
+			now.Add(-staleAfter).Format(time.RFC3339),
+		); err != nil {
+			return nil, err
+		}
+
+		result, err := c.ExecContext(
+			ctx,
+			"INSERT OR IGNORE INTO schema_migration_lock (id, locked_at) VALUES (1, ?);",
+			now.Format(time.RFC3339),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		if inserted == 1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return func(ctx context.Context) error {
+		_, err := c.ExecContext(ctx, "DELETE FROM schema_migration_lock WHERE id = 1;")
+		return err
+	}, nil
+}
+
+// discard
+// Closes the connection instead of returning it to the pool.
+func discard(c *sql.Conn) {
+	_ = c.Raw(func(any) error { return driver.ErrBadConn })
+}
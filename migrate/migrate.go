@@ -0,0 +1,783 @@
+// Package migrate runs versioned SQL migrations against an existing *sql.DB.
+//
+// Migrations are read from an fs.FS (e.g. os.DirFS or embed.FS) and need to follow the naming convention:
+//
+//	<version>.<name>.up.sql
+//	<version>.<name>.down.sql
+//
+// Every migration needs to have both the up and the down script. The applied migrations are tracked in the
+// schema_migration table, which is created if it does not exist.
+//
+// Migrations which need Go code are registered using WithGoMigration, and are applied in version order
+// together with the SQL migrations.
+//
+// Files named R.<name>.sql are repeatable migrations. They are (re)applied by Up, after the versioned
+// migrations, whenever their checksum changes. They are tracked in the schema_repeatable_migration table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MigrationFunc
+// Applies or reverts a Go migration inside the transaction of the migration.
+type MigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+type Migration struct {
+	Version    int
+	Name       string
+	UpScript   string
+	DownScript string
+	// Up and Down are set instead of the scripts for Go migrations.
+	Up   MigrationFunc
+	Down MigrationFunc
+	// Checksum is the hex encoded SHA-256 hash of the up script. Empty for Go migrations.
+	Checksum string
+}
+
+// RepeatableMigration
+// A migration read from a R.<name>.sql file, which is reapplied whenever its script changes.
+type RepeatableMigration struct {
+	Name   string
+	Script string
+	// Checksum is the hex encoded SHA-256 hash of the script.
+	Checksum string
+}
+
+// MigrationStatus
+// The state of a single migration, as reported by Status.
+type MigrationStatus struct {
+	Version int
+	Name    string
+	Applied bool
+	// Changed reports whether the up script changed after the migration was applied.
+	// For repeatable migrations, it reports whether the next Up reapplies the migration.
+	Changed bool
+	// Repeatable migrations have no version, and are listed after the versioned migrations.
+	Repeatable bool
+}
+
+type appliedMigration struct {
+	ID       int
+	Version  int
+	Name     string
+	Checksum *string
+}
+
+type Migrator struct {
+	db          *sql.DB
+	dialect     Dialect
+	migrations  []Migration
+	repeatables []RepeatableMigration
+	lockTimeout time.Duration
+	staleLock   time.Duration
+	warnf       func(format string, args ...any)
+
+	goMigrations []Migration
+}
+
+type Option func(*Migrator)
+
+// WithLockTimeout
+// Sets how long to wait for the migration lock held by another instance
+// before giving up. Defaults to 5 minutes.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithStaleLockTimeout
+// Sets how old the lock row on SQLite needs to be to be considered stale, e.g. left behind by
+// an instance which crashed, and be taken over. It needs to be longer than the migrations take
+// to run. Defaults to 1 hour.
+func WithStaleLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.staleLock = timeout
+	}
+}
+
+// WithWarnOnDrift
+// Reports the problems found by Verify using warnf (e.g. log.Printf), instead of refusing
+// to run the migrations.
+func WithWarnOnDrift(warnf func(format string, args ...any)) Option {
+	return func(m *Migrator) {
+		m.warnf = warnf
+	}
+}
+
+// WithGoMigration
+// Registers a migration implemented in Go. The version must not be used by any other migration.
+func WithGoMigration(version int, name string, up, down MigrationFunc) Option {
+	return func(m *Migrator) {
+		m.goMigrations = append(m.goMigrations, Migration{Version: version, Name: name, Up: up, Down: down})
+	}
+}
+
+// New
+// Reads and validates the migrations from the root of fsys. Use fs.Sub to read
+// the migrations from a subdirectory.
+func New(db *sql.DB, dialect Dialect, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	if err := dialect.validate(); err != nil {
+		return nil, err
+	}
+
+	migrations, repeatables, err := readMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Migrator{
+		db:          db,
+		dialect:     dialect,
+		migrations:  migrations,
+		repeatables: repeatables,
+		lockTimeout: defaultLockTimeout,
+		staleLock:   defaultStaleLockTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	if m.migrations, err = mergeGoMigrations(m.migrations, m.goMigrations); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Migrations
+// Returns the SQL and Go migrations, ordered by version.
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// RepeatableMigrations
+// Returns the repeatable migrations, ordered by name.
+func (m *Migrator) RepeatableMigrations() []RepeatableMigration {
+	return slices.Clone(m.repeatables)
+}
+
+// Up
+// Applies all the migrations newer than the last applied migration, followed by
+// the repeatable migrations which are new or changed since they were last applied.
+//
+// Up, Down and To refuse to run if Verify finds any problems, unless the Migrator was
+// created using WithWarnOnDrift.
+//
+// Every migration is applied in its own transaction. If a migration fails, the migrations
+// applied by this call are reverted using their down scripts. A failing repeatable migration
+// is rolled back, but does not revert the versioned migrations.
+//
+// Up, Down and To hold the migration lock while they run, so only a single
+// instance of a service migrates the database at a time.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(c *sql.Conn) error {
+		applied, err := m.applied(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		if err := m.checkDrift(applied); err != nil {
+			return err
+		}
+
+		if err := m.apply(ctx, c, m.pending(applied, m.latestVersion())); err != nil {
+			return err
+		}
+
+		return m.applyRepeatables(ctx, c)
+	})
+}
+
+// Down
+// Reverts the last n applied migrations.
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n < 1 {
+		return fmt.Errorf("invalid number of migrations to revert: %d", n)
+	}
+
+	return m.locked(ctx, func(c *sql.Conn) error {
+		applied, err := m.applied(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		if err := m.checkDrift(applied); err != nil {
+			return err
+		}
+
+		toRevert, err := m.lastApplied(applied, n)
+		if err != nil {
+			return err
+		}
+
+		return m.revert(ctx, c, toRevert)
+	})
+}
+
+// PlanUp
+// Returns the migrations and the repeatable migrations Up would apply, in the order they
+// would be applied, without applying them.
+func (m *Migrator) PlanUp(ctx context.Context) ([]Migration, []RepeatableMigration, error) {
+	c, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = c.Close() }()
+
+	applied, err := m.applied(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appliedRepeatables, err := m.appliedRepeatables(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var repeatables []RepeatableMigration
+	for _, r := range m.repeatables {
+		if appliedChecksum, found := appliedRepeatables[r.Name]; !found || appliedChecksum != r.Checksum {
+			repeatables = append(repeatables, r)
+		}
+	}
+
+	return m.pending(applied, m.latestVersion()), repeatables, nil
+}
+
+// PlanDown
+// Returns the migrations Down would revert, in the order they would be reverted, without
+// reverting them.
+func (m *Migrator) PlanDown(ctx context.Context, n int) ([]Migration, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of migrations to revert: %d", n)
+	}
+
+	c, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Close() }()
+
+	applied, err := m.applied(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.lastApplied(applied, n)
+}
+
+// To
+// Applies or reverts migrations until the version is the last applied migration.
+// Version 0 reverts all the migrations.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version != 0 && !slices.ContainsFunc(m.migrations, func(migration Migration) bool {
+		return migration.Version == version
+	}) {
+		return fmt.Errorf("migration with version %d not found", version)
+	}
+
+	return m.locked(ctx, func(c *sql.Conn) error {
+		applied, err := m.applied(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		if err := m.checkDrift(applied); err != nil {
+			return err
+		}
+
+		lastAppliedVersion := 0
+		if len(applied) > 0 {
+			lastAppliedVersion = applied[len(applied)-1].Version
+		}
+
+		if version < lastAppliedVersion {
+			var newer []appliedMigration
+			for _, a := range applied {
+				if a.Version > version {
+					newer = append(newer, a)
+				}
+			}
+
+			toRevert, err := m.lookup(newer)
+			if err != nil {
+				return err
+			}
+
+			slices.Reverse(toRevert)
+			return m.revert(ctx, c, toRevert)
+		}
+
+		return m.apply(ctx, c, m.pending(applied, version))
+	})
+}
+
+// Status
+// Returns all the migrations, either read from the file system or applied to the database,
+// ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	c, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Close() }()
+
+	applied, err := m.applied(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{Version: migration.Version, Name: migration.Name})
+	}
+
+	for _, a := range applied {
+		idx := slices.IndexFunc(statuses, func(s MigrationStatus) bool { return s.Version == a.Version })
+		if idx < 0 {
+			statuses = append(statuses, MigrationStatus{Version: a.Version, Name: a.Name, Applied: true})
+			continue
+		}
+
+		statuses[idx].Applied = true
+		statuses[idx].Changed = a.Checksum != nil && *a.Checksum != m.migrations[idx].Checksum
+	}
+
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return a.Version - b.Version })
+
+	appliedRepeatables, err := m.appliedRepeatables(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range m.repeatables {
+		appliedChecksum, found := appliedRepeatables[r.Name]
+		statuses = append(statuses, MigrationStatus{
+			Name:       r.Name,
+			Applied:    found,
+			Changed:    appliedChecksum != r.Checksum,
+			Repeatable: true,
+		})
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, c *sql.Conn, migrations []Migration) error {
+	var newlyAppliedMigrations []Migration
+
+	var migrationErr error
+	for _, migration := range migrations {
+		if err := inTx(ctx, c, func(tx *sql.Tx) error {
+			if err := migration.up(ctx, tx); err != nil {
+				return fmt.Errorf("failed to apply migration %d.%s: %w", migration.Version, migration.Name, err)
+			}
+
+			stmt := fmt.Sprintf(
+				"INSERT INTO schema_migration (version, name, checksum) VALUES (%s, %s, %s);",
+				m.dialect.placeholder(1),
+				m.dialect.placeholder(2),
+				m.dialect.placeholder(3),
+			)
+			_, err := tx.ExecContext(ctx, stmt, migration.Version, migration.Name, migration.Checksum)
+			return err
+		}); err != nil {
+			migrationErr = err
+			break
+		}
+
+		newlyAppliedMigrations = append(newlyAppliedMigrations, migration)
+	}
+
+	if migrationErr != nil {
+		slices.Reverse(newlyAppliedMigrations)
+		if err := m.revert(ctx, c, newlyAppliedMigrations); err != nil {
+			return fmt.Errorf("%s: %w", err.Error(), migrationErr)
+		}
+		return migrationErr
+	}
+
+	return nil
+}
+
+func (m *Migrator) revert(ctx context.Context, c *sql.Conn, migrations []Migration) error {
+	for _, migration := range migrations {
+		if err := inTx(ctx, c, func(tx *sql.Tx) error {
+			if err := migration.down(ctx, tx); err != nil {
+				return fmt.Errorf("failed to revert migration %d.%s: %w", migration.Version, migration.Name, err)
+			}
+
+			stmt := "DELETE FROM schema_migration WHERE version = " + m.dialect.placeholder(1)
+			_, err := tx.ExecContext(ctx, stmt, migration.Version)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyRepeatables
+// Applies the repeatable migrations which were never applied, or changed since they were last applied.
+func (m *Migrator) applyRepeatables(ctx context.Context, c *sql.Conn) error {
+	applied, err := m.appliedRepeatables(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range m.repeatables {
+		if appliedChecksum, found := applied[r.Name]; found && appliedChecksum == r.Checksum {
+			continue
+		}
+
+		if err := inTx(ctx, c, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, r.Script); err != nil {
+				return fmt.Errorf("failed to apply repeatable migration %s: %w", r.Name, err)
+			}
+
+			stmt := "DELETE FROM schema_repeatable_migration WHERE name = " + m.dialect.placeholder(1)
+			if _, err := tx.ExecContext(ctx, stmt, r.Name); err != nil {
+				return err
+			}
+
+			stmt = fmt.Sprintf(
+				"INSERT INTO schema_repeatable_migration (name, checksum) VALUES (%s, %s);",
+				m.dialect.placeholder(1),
+				m.dialect.placeholder(2),
+			)
+			_, err := tx.ExecContext(ctx, stmt, r.Name, r.Checksum)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (migration Migration) up(ctx context.Context, tx *sql.Tx) error {
+	if migration.Up != nil {
+		return migration.Up(ctx, tx)
+	}
+
+	_, err := tx.ExecContext(ctx, migration.UpScript)
+	return err
+}
+
+func (migration Migration) down(ctx context.Context, tx *sql.Tx) error {
+	if migration.Down != nil {
+		return migration.Down(ctx, tx)
+	}
+
+	_, err := tx.ExecContext(ctx, migration.DownScript)
+	return err
+}
+
+func inTx(ctx context.Context, c *sql.Conn, f func(tx *sql.Tx) error) error {
+	tx, err := c.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rollbackErr))
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applied
+// Returns the applied migrations, ordered by version.
+//
+// The statements of the Migrator are written in the syntax of its dialect, so they are run
+// on the connection directly rather than being translated by tql.
+func (m *Migrator) applied(ctx context.Context, c *sql.Conn) (_ []appliedMigration, err error) {
+	if err := m.ensureMigrationsSchema(ctx, c); err != nil {
+		return nil, err
+	}
+
+	const q = `
+		SELECT id, version, name, checksum
+		FROM schema_migration
+		ORDER BY version;`
+	rows, err := c.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}()
+
+	var applied []appliedMigration
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.ID, &a.Version, &a.Name, &a.Checksum); err != nil {
+			return nil, err
+		}
+
+		applied = append(applied, a)
+	}
+
+	return applied, rows.Err()
+}
+
+// appliedRepeatables
+// Returns the checksums of the applied repeatable migrations by name.
+func (m *Migrator) appliedRepeatables(ctx context.Context, c *sql.Conn) (_ map[string]string, err error) {
+	if _, err := c.ExecContext(ctx, m.dialect.createRepeatableSchemaTable()); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.QueryContext(ctx, "SELECT name, checksum FROM schema_repeatable_migration;")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}()
+
+	checksums := make(map[string]string)
+	for rows.Next() {
+		var name, checksum string
+		if err := rows.Scan(&name, &checksum); err != nil {
+			return nil, err
+		}
+
+		checksums[name] = checksum
+	}
+
+	return checksums, rows.Err()
+}
+
+func (m *Migrator) ensureMigrationsSchema(ctx context.Context, c *sql.Conn) error {
+	if _, err := c.ExecContext(ctx, m.dialect.createSchemaTable()); err != nil {
+		return err
+	}
+
+	// The table might have been created before the checksums were stored.
+	if _, err := c.ExecContext(ctx, "SELECT checksum FROM schema_migration WHERE 1 = 0;"); err != nil {
+		_, err = c.ExecContext(ctx, m.dialect.addChecksumColumn())
+		return err
+	}
+
+	return nil
+}
+
+// lookup
+// Finds the migrations matching the applied migrations, which are needed to revert them.
+func (m *Migrator) lookup(applied []appliedMigration) ([]Migration, error) {
+	migrations := make([]Migration, 0, len(applied))
+	for _, a := range applied {
+		idx := slices.IndexFunc(m.migrations, func(migration Migration) bool {
+			return migration.Version == a.Version
+		})
+		if idx < 0 {
+			return nil, fmt.Errorf("failed to find scripts for applied migration %d.%s", a.Version, a.Name)
+		}
+
+		migrations = append(migrations, m.migrations[idx])
+	}
+
+	return migrations, nil
+}
+
+// lastApplied
+// Returns the last n applied migrations, newest first.
+func (m *Migrator) lastApplied(applied []appliedMigration, n int) ([]Migration, error) {
+	n = min(n, len(applied))
+
+	migrations, err := m.lookup(applied[len(applied)-n:])
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(migrations)
+	return migrations, nil
+}
+
+// pending
+// Returns the migrations newer than the last applied migration, up to and including the version.
+func (m *Migrator) pending(applied []appliedMigration, version int) []Migration {
+	lastAppliedVersion := 0
+	if len(applied) > 0 {
+		lastAppliedVersion = applied[len(applied)-1].Version
+	}
+
+	var migrations []Migration
+	for _, migration := range m.migrations {
+		if migration.Version > lastAppliedVersion && migration.Version <= version {
+			migrations = append(migrations, migration)
+		}
+	}
+
+	return migrations
+}
+
+func (m *Migrator) latestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// readMigrations
+// Reads the migrations from the root directory of fsys, ordered by version, and
+// the repeatable migrations, ordered by name.
+func readMigrations(fsys fs.FS) ([]Migration, []RepeatableMigration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrations := make(map[int]Migration)
+	var repeatables []RepeatableMigration
+
+	for _, entry := range entries {
+		// Sanity checks - only root directory, needs to have a name by convention
+		// Name convention - migrationnumber.name.up.sql
+		//                   migrationnumber.name.down.sql
+		//                   R.name.sql
+		// Needs to have both up and down!
+
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		parts := strings.Split(entry.Name(), ".")
+		if len(parts) == 3 && parts[0] == "R" {
+			script, err := fs.ReadFile(fsys, entry.Name())
+			if err != nil {
+				return nil, nil, err
+			}
+
+			repeatables = append(repeatables, RepeatableMigration{
+				Name:     parts[1],
+				Script:   string(script),
+				Checksum: checksum(script),
+			})
+			continue
+		}
+
+		if len(parts) != 4 {
+			// Doesn't match the naming convention.
+			continue
+		}
+
+		migrationNumber, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		m := migrations[migrationNumber]
+
+		if m.Name != "" && m.Name != parts[1] {
+			return nil, nil, fmt.Errorf("found multiple migrations with version %d: %s, %s", migrationNumber, m.Name, parts[1])
+		}
+
+		m.Version = migrationNumber
+		m.Name = parts[1]
+
+		migrationContent, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		migrationScriptType := parts[2]
+		switch migrationScriptType {
+		case "up":
+			m.UpScript = string(migrationContent)
+			m.Checksum = checksum(migrationContent)
+		case "down":
+			m.DownScript = string(migrationContent)
+		default:
+			return nil, nil, fmt.Errorf("unrecognized script type: %s", migrationScriptType)
+		}
+
+		migrations[migrationNumber] = m
+	}
+
+	if err := validateFoundMigrationFiles(migrations); err != nil {
+		return nil, nil, err
+	}
+
+	sorted := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		sorted = append(sorted, migration)
+	}
+
+	slices.SortFunc(sorted, func(a, b Migration) int { return a.Version - b.Version })
+	slices.SortFunc(repeatables, func(a, b RepeatableMigration) int { return strings.Compare(a.Name, b.Name) })
+	return sorted, repeatables, nil
+}
+
+// mergeGoMigrations
+// Adds the Go migrations to the SQL migrations, ordered by version.
+func mergeGoMigrations(migrations []Migration, goMigrations []Migration) ([]Migration, error) {
+	merged := slices.Clone(migrations)
+
+	for _, goMigration := range goMigrations {
+		if goMigration.Version < 1 {
+			return nil, fmt.Errorf("invalid version %d for %s, versions start at 1", goMigration.Version, goMigration.Name)
+		}
+
+		if goMigration.Up == nil || goMigration.Down == nil {
+			return nil, fmt.Errorf("go migration %s needs both the up and the down function", goMigration.Name)
+		}
+
+		idx := slices.IndexFunc(merged, func(migration Migration) bool {
+			return migration.Version == goMigration.Version
+		})
+		if idx >= 0 {
+			return nil, fmt.Errorf(
+				"found multiple migrations with version %d: %s, %s",
+				goMigration.Version,
+				merged[idx].Name,
+				goMigration.Name,
+			)
+		}
+
+		merged = append(merged, goMigration)
+	}
+
+	slices.SortFunc(merged, func(a, b Migration) int { return a.Version - b.Version })
+	return merged, nil
+}
+
+func validateFoundMigrationFiles(migrations map[int]Migration) error {
+	for _, migration := range migrations {
+		if migration.Version < 1 {
+			return fmt.Errorf("invalid version %d for %s, versions start at 1", migration.Version, migration.Name)
+		}
+
+		if migration.DownScript == "" {
+			return fmt.Errorf("failed to find 'down' script for %s", migration.Name)
+		}
+
+		if migration.UpScript == "" {
+			return fmt.Errorf("failed to find 'up' script for %s", migration.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func Test_ReadMigrations_Orders_Migrations_By_Version(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"10.add_index.up.sql":     {Data: []byte("CREATE INDEX foo_value ON foo (value);")},
+		"10.add_index.down.sql":   {Data: []byte("DROP INDEX foo_value;")},
+		"2.create_foo.up.sql":     {Data: []byte("CREATE TABLE foo (id text, value text);")},
+		"2.create_foo.down.sql":   {Data: []byte("DROP TABLE foo;")},
+		"README.md":               {Data: []byte("not a migration")},
+		"notes.sql":               {Data: []byte("-- not a migration")},
+		"nested/3.bar.up.sql":     {Data: []byte("CREATE TABLE bar (id text);")},
+		"nested/3.bar.down.sql":   {Data: []byte("DROP TABLE bar;")},
+		"1.create_users.up.sql":   {Data: []byte("CREATE TABLE users (id text);")},
+		"1.create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+
+	// Act
+	migrations, _, err := readMigrations(fsys)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expectedVersions := []int{1, 2, 10}
+	if len(migrations) != len(expectedVersions) {
+		t.Fatalf("expected len %d found %d", len(expectedVersions), len(migrations))
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != expectedVersions[i] {
+			t.Fatalf("value '%d' does not equal expected '%d'", migration.Version, expectedVersions[i])
+		}
+	}
+
+	if migrations[1].Name != "create_foo" || migrations[1].DownScript != "DROP TABLE foo;" {
+		t.Fatalf("unexpected migration '%v'", migrations[1])
+	}
+}
+
+func Test_ReadMigrations_Errors(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		expected string
+	}{
+		{
+			name: "missing down script",
+			fsys: fstest.MapFS{
+				"1.create_foo.up.sql": {Data: []byte("CREATE TABLE foo (id text);")},
+			},
+			expected: "failed to find 'down' script for create_foo",
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text);")},
+				"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+				"1.create_bar.up.sql":   {Data: []byte("CREATE TABLE bar (id text);")},
+			},
+			expected: "found multiple migrations with version 1",
+		},
+		{
+			name: "unrecognized script type",
+			fsys: fstest.MapFS{
+				"1.create_foo.sideways.sql": {Data: []byte("CREATE TABLE foo (id text);")},
+			},
+			expected: "unrecognized script type: sideways",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			_, _, err := readMigrations(test.fsys)
+
+			// Assert
+			if err == nil {
+				t.Fatalf("expected error, found nil")
+			}
+
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("value '%s' does not contain expected '%s'", err.Error(), test.expected)
+			}
+		})
+	}
+}
+
+func Test_ReadMigrations_Reads_Repeatable_Migrations(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text);")},
+		"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+		"R.foo_view.sql":        {Data: []byte("CREATE OR REPLACE VIEW foo_view AS SELECT id FROM foo;")},
+		"R.bar_function.sql":    {Data: []byte("CREATE OR REPLACE FUNCTION bar() RETURNS integer AS 'SELECT 1' LANGUAGE SQL;")},
+	}
+
+	// Act
+	migrations, repeatables, err := readMigrations(fsys)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(migrations) != 1 {
+		t.Fatalf("expected len %d found %d", 1, len(migrations))
+	}
+
+	if len(repeatables) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(repeatables))
+	}
+
+	if repeatables[0].Name != "bar_function" || repeatables[1].Name != "foo_view" {
+		t.Fatalf("unexpected repeatable migrations '%v'", repeatables)
+	}
+
+	expectedChecksum := checksum([]byte("CREATE OR REPLACE VIEW foo_view AS SELECT id FROM foo;"))
+	if repeatables[1].Checksum != expectedChecksum {
+		t.Fatalf("value '%s' does not equal expected '%s'", repeatables[1].Checksum, expectedChecksum)
+	}
+}
+
+func Test_New_Interleaves_Go_Migrations_By_Version(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text);")},
+		"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+		"3.create_bar.up.sql":   {Data: []byte("CREATE TABLE bar (id text);")},
+		"3.create_bar.down.sql": {Data: []byte("DROP TABLE bar;")},
+	}
+
+	noop := func(context.Context, *sql.Tx) error { return nil }
+
+	// Act
+	m, err := New(nil, SQLite, fsys, WithGoMigration(2, "backfill_foo", noop, noop))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	migrations := m.Migrations()
+
+	expectedNames := []string{"create_foo", "backfill_foo", "create_bar"}
+	if len(migrations) != len(expectedNames) {
+		t.Fatalf("expected len %d found %d", len(expectedNames), len(migrations))
+	}
+
+	for i, migration := range migrations {
+		if migration.Name != expectedNames[i] {
+			t.Fatalf("value '%s' does not equal expected '%s'", migration.Name, expectedNames[i])
+		}
+	}
+
+	if migrations[1].Up == nil || migrations[1].Checksum != "" {
+		t.Fatalf("unexpected migration '%v'", migrations[1])
+	}
+}
+
+func Test_New_Go_Migration_Errors(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text);")},
+		"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+	}
+
+	noop := func(context.Context, *sql.Tx) error { return nil }
+
+	tests := []struct {
+		name     string
+		opt      Option
+		expected string
+	}{
+		{
+			name:     "duplicate version",
+			opt:      WithGoMigration(1, "backfill_foo", noop, noop),
+			expected: "found multiple migrations with version 1: create_foo, backfill_foo",
+		},
+		{
+			name:     "missing down function",
+			opt:      WithGoMigration(2, "backfill_foo", noop, nil),
+			expected: "go migration backfill_foo needs both the up and the down function",
+		},
+		{
+			name:     "invalid version",
+			opt:      WithGoMigration(0, "backfill_foo", noop, noop),
+			expected: "invalid version 0 for backfill_foo",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			_, err := New(nil, SQLite, fsys, test.opt)
+
+			// Assert
+			if err == nil {
+				t.Fatalf("expected error, found nil")
+			}
+
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("value '%s' does not contain expected '%s'", err.Error(), test.expected)
+			}
+		})
+	}
+}
+
+func Test_LockWaitSeconds_Rounds_Up(t *testing.T) {
+	tests := []struct {
+		timeout  time.Duration
+		expected int
+	}{
+		{timeout: 0, expected: 1},
+		{timeout: 200 * time.Millisecond, expected: 1},
+		{timeout: time.Second, expected: 1},
+		{timeout: 1500 * time.Millisecond, expected: 2},
+		{timeout: 5 * time.Minute, expected: 300},
+	}
+
+	for _, test := range tests {
+		t.Run(test.timeout.String(), func(t *testing.T) {
+			// Act
+			seconds := lockWaitSeconds(test.timeout)
+
+			// Assert
+			if seconds != test.expected {
+				t.Fatalf("value '%d' does not equal expected '%d'", seconds, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrDrift = errors.New("migrate: migrations do not match the applied migrations")
+
+// DriftError
+// Lists the problems found when comparing the migrations with the applied migrations.
+type DriftError struct {
+	Problems []string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("%s:\n\t%s", ErrDrift.Error(), strings.Join(e.Problems, "\n\t"))
+}
+
+func (e *DriftError) Unwrap() error {
+	return ErrDrift
+}
+
+// Verify
+// Compares the migrations with the migrations applied to the database, and returns a *DriftError if:
+//   - the up script of an applied migration changed after it was applied,
+//   - an applied migration can no longer be found,
+//   - a migration is not applied, but a newer migration is (a gap),
+//   - the migrations were applied out of version order.
+//
+// Migrations applied before checksums were stored, Go migrations and repeatable migrations
+// are not checked for changes.
+func (m *Migrator) Verify(ctx context.Context) error {
+	c, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	applied, err := m.applied(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return m.verify(applied)
+}
+
+func (m *Migrator) verify(applied []appliedMigration) error {
+	var problems []string
+
+	appliedVersions := make(map[int]struct{}, len(applied))
+	lastAppliedVersion := 0
+
+	for _, a := range applied {
+		appliedVersions[a.Version] = struct{}{}
+		lastAppliedVersion = max(lastAppliedVersion, a.Version)
+
+		idx := slices.IndexFunc(m.migrations, func(migration Migration) bool {
+			return migration.Version == a.Version
+		})
+		if idx < 0 {
+			problems = append(problems, fmt.Sprintf("applied migration %d.%s not found", a.Version, a.Name))
+			continue
+		}
+
+		migration := m.migrations[idx]
+		if a.Checksum != nil && *a.Checksum != migration.Checksum {
+			problems = append(
+				problems,
+				fmt.Sprintf("migration %d.%s changed after it was applied", migration.Version, migration.Name),
+			)
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if _, found := appliedVersions[migration.Version]; found || migration.Version > lastAppliedVersion {
+			continue
+		}
+
+		problems = append(
+			problems,
+			fmt.Sprintf("migration %d.%s is not applied, but newer migrations are", migration.Version, migration.Name),
+		)
+	}
+
+	// The ids reflect the order in which the migrations were applied.
+	byID := slices.Clone(applied)
+	slices.SortFunc(byID, func(a, b appliedMigration) int { return a.ID - b.ID })
+
+	for i := 1; i < len(byID); i++ {
+		if byID[i].Version < byID[i-1].Version {
+			problems = append(
+				problems,
+				fmt.Sprintf(
+					"migration %d.%s was applied after migration %d.%s",
+					byID[i].Version,
+					byID[i].Name,
+					byID[i-1].Version,
+					byID[i-1].Name,
+				),
+			)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &DriftError{Problems: problems}
+	}
+
+	return nil
+}
+
+// checkDrift
+// Fails if Verify finds any problems, or only reports them if the Migrator warns on drift.
+func (m *Migrator) checkDrift(applied []appliedMigration) error {
+	err := m.verify(applied)
+
+	var driftErr *DriftError
+	if m.warnf == nil || !errors.As(err, &driftErr) {
+		return err
+	}
+
+	for _, problem := range driftErr.Problems {
+		m.warnf("migrate: %s", problem)
+	}
+
+	return nil
+}
+
+func checksum(script []byte) string {
+	hash := sha256.Sum256(script)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_Verify_Reports_Drift(t *testing.T) {
+	// Arrange
+	m := Migrator{
+		migrations: []Migration{
+			{Version: 1, Name: "create_foo", Checksum: checksum([]byte("CREATE TABLE foo (id text);"))},
+			{Version: 2, Name: "create_bar", Checksum: checksum([]byte("CREATE TABLE bar (id text);"))},
+			{Version: 3, Name: "create_baz", Checksum: checksum([]byte("CREATE TABLE baz (id text);"))},
+			{Version: 4, Name: "create_qux", Checksum: checksum([]byte("CREATE TABLE qux (id text);"))},
+		},
+	}
+
+	changed := checksum([]byte("CREATE TABLE foo (id text, value text);"))
+	applied := []appliedMigration{
+		{ID: 1, Version: 1, Name: "create_foo", Checksum: &changed},
+		{ID: 3, Version: 3, Name: "create_baz"},
+		{ID: 2, Version: 5, Name: "create_quux"},
+	}
+
+	// Act
+	err := m.verify(applied)
+
+	// Assert
+	if !errors.Is(err, ErrDrift) {
+		t.Fatalf("expected '%v' found '%v'", ErrDrift, err)
+	}
+
+	var driftErr *DriftError
+	if !errors.As(err, &driftErr) {
+		t.Fatalf("expected *DriftError found %T", err)
+	}
+
+	expectedProblems := []string{
+		"migration 1.create_foo changed after it was applied",
+		"applied migration 5.create_quux not found",
+		"migration 2.create_bar is not applied, but newer migrations are",
+		"migration 4.create_qux is not applied, but newer migrations are",
+		"migration 3.create_baz was applied after migration 5.create_quux",
+	}
+	if strings.Join(driftErr.Problems, "\n") != strings.Join(expectedProblems, "\n") {
+		t.Fatalf("value '%v' does not equal expected '%v'", driftErr.Problems, expectedProblems)
+	}
+}
+
+func Test_Verify_Without_Drift(t *testing.T) {
+	// Arrange
+	sum := checksum([]byte("CREATE TABLE foo (id text);"))
+	m := Migrator{
+		migrations: []Migration{
+			{Version: 1, Name: "create_foo", Checksum: sum},
+			{Version: 2, Name: "create_bar", Checksum: checksum([]byte("CREATE TABLE bar (id text);"))},
+		},
+	}
+
+	applied := []appliedMigration{{ID: 1, Version: 1, Name: "create_foo", Checksum: &sum}}
+
+	// Act
+	err := m.verify(applied)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+}
@@ -0,0 +1,75 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var ErrNoMoreResultSets = errors.New("sql: no more result sets")
+
+// ResultSetReader
+// Reads the result sets returned by a single query (e.g. a stored procedure or a batch
+// of statements) in the order in which the database returned them.
+//
+// The reader holds on to the underlying sql.Rows, so it *must* be closed once the caller is done with it.
+type ResultSetReader struct {
+	rows    *sql.Rows
+	started bool
+}
+
+// QueryMulti
+// Queries the database and returns a reader over all the result sets returned by the query.
+// Every result set is read by calling tql.Next with the type the result set maps to.
+//
+// Parameters are translated in the same way as in tql.Query.
+func QueryMulti(ctx context.Context, q Querier, query string, params ...any) (*ResultSetReader, error) {
+	d, err := dialectOf(q)
+	if err != nil {
+		return nil, err
+	}
+
+	parameterisedQuery, args, err := translateParams(d, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, parameterisedQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResultSetReader{rows: rows}, nil
+}
+
+// Next
+// Reads the next result set from the reader and maps all of its rows to T, using the same
+// mapping as tql.Query. If the result set is empty, an empty slice of type T is returned.
+//
+// If the reader has no result sets left, this function returns tql.ErrNoMoreResultSets.
+func Next[T any](r *ResultSetReader) ([]T, error) {
+	if r.rows == nil {
+		return nil, ErrNoMoreResultSets
+	}
+
+	if r.started && !r.rows.NextResultSet() {
+		if err := r.rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrNoMoreResultSets
+	}
+	r.started = true
+
+	return scanRows(r.rows, make([]T, 0))
+}
+
+// Close
+// Closes the underlying sql.Rows, discarding any result sets that were not read.
+func (r *ResultSetReader) Close() error {
+	if r.rows == nil {
+		return nil
+	}
+
+	return r.rows.Close()
+}
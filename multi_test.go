@@ -0,0 +1,62 @@
+package tql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func Test_QueryMulti_Reads_Result_Sets_In_Order(t *testing.T) {
+	// Arrange
+	type foo struct {
+		ID    string `db:"id"`
+		Value string `db:"value"`
+	}
+
+	db, _ := newFakeDB(
+		fakeResultSet{
+			columns: []string{"id", "value"},
+			rows:    [][]driver.Value{{"1", "a"}, {"2", "b"}},
+		},
+		fakeResultSet{
+			columns: []string{"count"},
+			rows:    [][]driver.Value{{int64(2)}},
+		},
+	)
+	defer func() { _ = db.Close() }()
+
+	// Act
+	reader, err := QueryMulti(context.Background(), db, "CALL foos();")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+	defer func() { _ = reader.Close() }()
+
+	foos, fooErr := Next[foo](reader)
+	counts, countErr := Next[int](reader)
+	_, endErr := Next[int](reader)
+
+	// Assert
+	if fooErr != nil {
+		t.Fatalf("unexpected err: %s", fooErr.Error())
+	}
+	if countErr != nil {
+		t.Fatalf("unexpected err: %s", countErr.Error())
+	}
+
+	if len(foos) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(foos))
+	}
+	if foos[1].ID != "2" || foos[1].Value != "b" {
+		t.Fatalf("unexpected value '%v'", foos[1])
+	}
+
+	if len(counts) != 1 || counts[0] != 2 {
+		t.Fatalf("unexpected value '%v'", counts)
+	}
+
+	if !errors.Is(endErr, ErrNoMoreResultSets) {
+		t.Fatalf("expected '%v' found '%v'", ErrNoMoreResultSets, endErr)
+	}
+}
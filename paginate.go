@@ -0,0 +1,501 @@
+package tql
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrInvalidCursor = errors.New("sql: invalid pagination cursor")
+
+// cursorKey
+// The key used to sign the pagination cursors. Defaults to a random key, which means
+// cursors are only valid within the process which issued them.
+var cursorKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// SetCursorKey
+// Sets the key used to sign and verify the pagination cursors. When running multiple
+// instances of a service, all of them need to use the same key for their cursors to be
+// accepted by each other.
+//
+// Not safe for concurrent use with tql.Paginate, call it on startup.
+func SetCursorKey(key []byte) error {
+	if len(key) < 16 {
+		return fmt.Errorf("cursor key must be at least 16 bytes long")
+	}
+
+	cursorKey = bytes.Clone(key)
+	return nil
+}
+
+// Page
+// A single page of results returned by tql.Paginate.
+//
+// Next and Previous are opaque cursors pointing to the following and preceding pages.
+// They are empty if there is no such page.
+type Page[T any] struct {
+	Items    []T
+	Next     string
+	Previous string
+}
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+type cursorDirection string
+
+const (
+	cursorNext     cursorDirection = "next"
+	cursorPrevious cursorDirection = "previous"
+)
+
+type cursorPayload struct {
+	Direction cursorDirection `json:"d"`
+	Columns   []string        `json:"c"`
+	Values    []cursorValue   `json:"v"`
+}
+
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// Paginate
+// Queries a single page of at most limit results using keyset pagination.
+//
+// The base query is wrapped as a subquery and filtered on the orderBy columns, which *must* be
+// columns returned by the query and tagged on T with the 'db' tag. The columns are sorted in ascending
+// order, unless suffixed with DESC (e.g. "created_at DESC"). The last column should be unique
+// (e.g. the primary key), so the ordering is stable.
+//
+// An empty cursor returns the first page. Otherwise, the cursor must be one of the cursors
+// returned in a previous tql.Page for the same orderBy columns.
+//
+// Parameters are translated in the same way as in tql.Query.
+func Paginate[T any](
+	ctx context.Context,
+	q Querier,
+	query string,
+	orderBy []string,
+	cursor string,
+	limit int,
+	params ...any,
+) (Page[T], error) {
+	var page Page[T]
+
+	if limit < 1 {
+		return page, fmt.Errorf("invalid page limit: %d", limit)
+	}
+
+	keys, err := parseOrderBy(orderBy)
+	if err != nil {
+		return page, err
+	}
+
+	fieldIndices, err := sortFieldIndices(reflect.TypeFor[T](), keys)
+	if err != nil {
+		return page, err
+	}
+
+	direction := cursorNext
+	var after []any
+
+	if cursor != "" {
+		payload, err := decodeCursor(cursor, keys)
+		if err != nil {
+			return page, err
+		}
+
+		direction = payload.Direction
+		if after, err = payload.values(); err != nil {
+			return page, err
+		}
+	}
+
+	d, err := dialectOf(q)
+	if err != nil {
+		return page, err
+	}
+
+	parameterisedQuery, args, err := translateParams(d, query, params...)
+	if err != nil {
+		return page, err
+	}
+
+	pageQuery, args, err := buildPageQuery(d, parameterisedQuery, args, keys, after, direction == cursorPrevious, limit)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := q.QueryContext(ctx, pageQuery, args...)
+	if err != nil {
+		return page, err
+	}
+
+	if rows == nil {
+		return page, nil
+	}
+
+	defer func() {
+		if rows.Err() != nil {
+			return
+		}
+
+		if closeErr := rows.Close(); closeErr != nil {
+			err = errors.Join(closeErr, err)
+		}
+	}()
+
+	items, err := scanRows(rows, make([]T, 0, limit+1))
+	if err != nil {
+		return page, err
+	}
+
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	if direction == cursorPrevious {
+		slices.Reverse(items)
+	}
+
+	page.Items = items
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	if hasMore || direction == cursorPrevious {
+		if page.Next, err = encodeCursor(cursorNext, keys, fieldIndices, items[len(items)-1]); err != nil {
+			return page, err
+		}
+	}
+
+	if (hasMore && direction == cursorPrevious) || (cursor != "" && direction == cursorNext) {
+		if page.Previous, err = encodeCursor(cursorPrevious, keys, fieldIndices, items[0]); err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
+}
+
+// buildPageQuery
+// Wraps the already parameterised query and appends the keyset predicate and
+// the ORDER BY and LIMIT clauses. When reversed, the rows are fetched backwards
+// from the cursor.
+func buildPageQuery(
+	d Dialect,
+	query string,
+	args []any,
+	keys []sortKey,
+	after []any,
+	reversed bool,
+	limit int,
+) (string, []any, error) {
+	query = strings.TrimRightFunc(query, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ';'
+	})
+
+	var result strings.Builder
+	result.WriteString("SELECT * FROM (")
+	result.WriteString(query)
+	// Oracle doesn't support AS before the alias of a table.
+	result.WriteString(") tql_page")
+
+	resultArgs := slices.Clone(args)
+
+	if len(after) > 0 {
+		result.WriteString(" WHERE ")
+
+		for i := range keys {
+			if i > 0 {
+				result.WriteString(" OR ")
+			}
+			result.WriteRune('(')
+
+			for j := 0; j <= i; j++ {
+				if j > 0 {
+					result.WriteString(" AND ")
+				}
+
+				operator := "="
+				if j == i {
+					operator = ">"
+					if keys[j].desc != reversed {
+						operator = "<"
+					}
+				}
+
+				placeholder := d.Placeholder(len(resultArgs) + 1)
+
+				result.WriteString(keys[j].column)
+				result.WriteString(" " + operator + " ")
+				result.WriteString(placeholder)
+				resultArgs = append(resultArgs, after[j])
+			}
+
+			result.WriteRune(')')
+		}
+	}
+
+	result.WriteString(" ORDER BY ")
+	for i, key := range keys {
+		if i > 0 {
+			result.WriteString(", ")
+		}
+
+		result.WriteString(key.column)
+		if key.desc != reversed {
+			result.WriteString(" DESC")
+		} else {
+			result.WriteString(" ASC")
+		}
+	}
+
+	result.WriteString(" ")
+	result.WriteString(d.Limit(limit+1, -1))
+
+	return result.String(), resultArgs, nil
+}
+
+func parseOrderBy(orderBy []string) ([]sortKey, error) {
+	if len(orderBy) == 0 {
+		return nil, fmt.Errorf("pagination requires at least one order by column")
+	}
+
+	keys := make([]sortKey, len(orderBy))
+	for i, o := range orderBy {
+		parts := strings.Fields(o)
+
+		switch {
+		case len(parts) == 1:
+		case len(parts) == 2 && strings.EqualFold(parts[1], "ASC"):
+		case len(parts) == 2 && strings.EqualFold(parts[1], "DESC"):
+			keys[i].desc = true
+		default:
+			return nil, fmt.Errorf("invalid order by column: %s", o)
+		}
+
+		for _, c := range parts[0] {
+			if !(unicode.IsLetter(c) || unicode.IsNumber(c) || c == '_') {
+				return nil, fmt.Errorf("invalid order by column: %s", o)
+			}
+		}
+
+		keys[i].column = parts[0]
+	}
+
+	return keys, nil
+}
+
+func sortFieldIndices(typ reflect.Type, keys []sortKey) ([]int, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("invalid type %s, pagination requires a struct", typ)
+	}
+
+	indices := make([]int, len(keys))
+
+KeyLoop:
+	for i, key := range keys {
+		for j := range typ.NumField() {
+			tag, foundTag := typ.Field(j).Tag.Lookup("db")
+			if !foundTag {
+				continue
+			}
+
+			if name, _ := parseTag(tag); name == key.column {
+				indices[i] = j
+				continue KeyLoop
+			}
+		}
+
+		return nil, fmt.Errorf("no matching field found for order by column: %s", key.column)
+	}
+
+	return indices, nil
+}
+
+func encodeCursor(direction cursorDirection, keys []sortKey, fieldIndices []int, item any) (string, error) {
+	payload := cursorPayload{
+		Direction: direction,
+		Columns:   make([]string, len(keys)),
+		Values:    make([]cursorValue, len(keys)),
+	}
+
+	value := reflect.ValueOf(item)
+	for i, key := range keys {
+		payload.Columns[i] = key.column
+
+		field := value.Field(fieldIndices[i])
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				return "", fmt.Errorf("cannot paginate on NULL value of column: %s", key.column)
+			}
+			field = field.Elem()
+		}
+
+		v, err := newCursorValue(field.Interface())
+		if err != nil {
+			return "", fmt.Errorf("cannot paginate on column %s: %w", key.column, err)
+		}
+		payload.Values[i] = v
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write(data)
+
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(data) + "." + encoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func decodeCursor(cursor string, keys []sortKey) (cursorPayload, error) {
+	var payload cursorPayload
+
+	encodedData, encodedSignature, found := strings.Cut(cursor, ".")
+	if !found {
+		return payload, ErrInvalidCursor
+	}
+
+	encoding := base64.RawURLEncoding
+
+	data, err := encoding.DecodeString(encodedData)
+	if err != nil {
+		return payload, ErrInvalidCursor
+	}
+
+	signature, err := encoding.DecodeString(encodedSignature)
+	if err != nil {
+		return payload, ErrInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write(data)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return payload, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return payload, ErrInvalidCursor
+	}
+
+	if payload.Direction != cursorNext && payload.Direction != cursorPrevious {
+		return payload, ErrInvalidCursor
+	}
+
+	if len(payload.Columns) != len(keys) || len(payload.Values) != len(keys) {
+		return payload, ErrInvalidCursor
+	}
+
+	for i, key := range keys {
+		if payload.Columns[i] != key.column {
+			return payload, ErrInvalidCursor
+		}
+	}
+
+	return payload, nil
+}
+
+func (p cursorPayload) values() ([]any, error) {
+	values := make([]any, len(p.Values))
+	for i, v := range p.Values {
+		value, err := v.value()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// newCursorValue
+// Stores the value together with its type, so it is passed back to the
+// driver as the same type when the cursor is decoded.
+func newCursorValue(value any) (cursorValue, error) {
+	if t, ok := value.(time.Time); ok {
+		return cursorValue{Type: "time", Value: t.Format(time.RFC3339Nano)}, nil
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.String:
+		return cursorValue{Type: "string", Value: v.String()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(v.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(v.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(v.Float(), 'g', -1, 64)}, nil
+	case reflect.Bool:
+		return cursorValue{Type: "bool", Value: strconv.FormatBool(v.Bool())}, nil
+	case reflect.Slice:
+		if b, ok := value.([]byte); ok {
+			return cursorValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(b)}, nil
+		}
+	default: // no-op
+	}
+
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return cursorValue{Type: "string", Value: stringer.String()}, nil
+	}
+
+	return cursorValue{}, fmt.Errorf("unsupported type %T", value)
+}
+
+func (v cursorValue) value() (any, error) {
+	var (
+		value any
+		err   error
+	)
+
+	switch v.Type {
+	case "string":
+		value = v.Value
+	case "int":
+		value, err = strconv.ParseInt(v.Value, 10, 64)
+	case "uint":
+		value, err = strconv.ParseUint(v.Value, 10, 64)
+	case "float":
+		value, err = strconv.ParseFloat(v.Value, 64)
+	case "bool":
+		value, err = strconv.ParseBool(v.Value)
+	case "time":
+		value, err = time.Parse(time.RFC3339Nano, v.Value)
+	case "bytes":
+		value, err = base64.StdEncoding.DecodeString(v.Value)
+	default:
+		return nil, ErrInvalidCursor
+	}
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return value, nil
+}
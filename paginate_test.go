@@ -0,0 +1,167 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+type testPaginated struct {
+	ID    int64  `db:"id"`
+	Value string `db:"value"`
+}
+
+func Test_Paginate_First_Page(t *testing.T) {
+	// Arrange
+	err := SetActiveDriver("postgres")
+	if err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, c := newFakeDB(fakeResultSet{
+		columns: []string{"id", "value"},
+		rows:    [][]driver.Value{{int64(3), "c"}, {int64(2), "b"}, {int64(1), "a"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	page, err := Paginate[testPaginated](
+		context.Background(),
+		db,
+		"SELECT id, value FROM foo WHERE value <> :value;",
+		[]string{"id DESC"},
+		"",
+		2,
+		map[string]any{"value": "z"},
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT * FROM (SELECT id, value FROM foo WHERE value <> $1) tql_page ORDER BY id DESC LIMIT 3"
+	if c.query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", c.query, expectedQuery)
+	}
+
+	if len(page.Items) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(page.Items))
+	}
+
+	if page.Next == "" {
+		t.Fatalf("unexpected empty 'Next'")
+	}
+
+	if page.Previous != "" {
+		t.Fatalf("unexpected 'Previous' on first page")
+	}
+}
+
+func Test_Paginate_Next_Page_From_Cursor(t *testing.T) {
+	// Arrange
+	err := SetActiveDriver("postgres")
+	if err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	keys := []sortKey{{column: "value"}, {column: "id", desc: true}}
+	cursor, err := encodeCursor(cursorNext, keys, []int{1, 0}, testPaginated{ID: 2, Value: "b"})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	db, c := newFakeDB(fakeResultSet{
+		columns: []string{"id", "value"},
+		rows:    [][]driver.Value{{int64(1), "c"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	page, err := Paginate[testPaginated](
+		context.Background(),
+		db,
+		"SELECT id, value FROM foo",
+		[]string{"value", "id DESC"},
+		cursor,
+		2,
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT * FROM (SELECT id, value FROM foo) tql_page " +
+		"WHERE (value > $1) OR (value = $2 AND id < $3) ORDER BY value ASC, id DESC LIMIT 3"
+	if c.query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", c.query, expectedQuery)
+	}
+
+	if len(c.args) != 3 || c.args[0].Value != "b" || c.args[2].Value != int64(2) {
+		t.Fatalf("unexpected args '%v'", c.args)
+	}
+
+	if len(page.Items) != 1 {
+		t.Fatalf("expected len %d found %d", 1, len(page.Items))
+	}
+
+	if page.Next != "" {
+		t.Fatalf("unexpected 'Next' on last page")
+	}
+
+	if page.Previous == "" {
+		t.Fatalf("unexpected empty 'Previous'")
+	}
+}
+
+func Test_Paginate_Rejects_Tampered_Cursor(t *testing.T) {
+	// Arrange
+	keys := []sortKey{{column: "id"}}
+	cursor, err := encodeCursor(cursorNext, keys, []int{0}, testPaginated{ID: 2})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	db, _ := newFakeDB()
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, err = Paginate[testPaginated](context.Background(), db, "SELECT id FROM foo", []string{"id"}, "x"+cursor, 2)
+
+	// Assert
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected '%v' found '%v'", ErrInvalidCursor, err)
+	}
+}
+
+// nilRowsQuerier
+// A Querier returning nil rows, as the mocks of Querier might.
+type nilRowsQuerier struct{}
+
+func (nilRowsQuerier) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, nil
+}
+func (nilRowsQuerier) QueryRowContext(context.Context, string, ...any) *sql.Row { return nil }
+
+func Test_Paginate_Nil_Rows(t *testing.T) {
+	// Arrange
+	err := SetActiveDriver("postgres")
+	if err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	// Act
+	page, err := Paginate[testPaginated](context.Background(), nilRowsQuerier{}, "SELECT id, value FROM foo;", []string{"id"}, "", 2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(page.Items) != 0 || page.Next != "" || page.Previous != "" {
+		t.Fatalf("unexpected page '%v'", page)
+	}
+}
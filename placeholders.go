@@ -0,0 +1,193 @@
+package tql
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+var portablePlaceholders atomic.Bool
+
+// SetPortablePlaceholders
+// Enables or disables the portable positional parameters. When enabled, the queries using positional
+// parameters can be written using either ? or $n, and the parameters are rewritten into the syntax
+// of the dialect of the database handle, e.g.
+//
+//	SELECT * FROM foo WHERE id = $1 OR parent_id = $1;
+//
+// is executed as SELECT * FROM foo WHERE id = ? OR parent_id = ?; with the argument passed twice on MySQL,
+// and
+//
+//	SELECT * FROM foo WHERE id = ? AND name = ?;
+//
+// as SELECT * FROM foo WHERE id = $1 AND name = $2; on Postgres.
+//
+// The parameters inside string literals, dollar-quoted strings, quoted identifiers and comments are left as is. Other uses of ?,
+// such as the jsonb operators of Postgres, are rewritten as well, so they *must not* be used in the queries
+// with positional parameters while this is enabled. Queries mixing ? or $n with named parameters are
+// rejected. Disabled by default.
+func SetPortablePlaceholders(enabled bool) {
+	portablePlaceholders.Store(enabled)
+}
+
+// positionalArgs
+// Returns the query with positional parameters and its arguments, rewriting the parameters into the
+// syntax of the dialect if the portable placeholders are enabled.
+func positionalArgs(d Dialect, query string, params []any) (string, []any, error) {
+	if !portablePlaceholders.Load() {
+		return query, params, nil
+	}
+
+	return rewritePlaceholders(d, query, params)
+}
+
+// rewritePlaceholders
+// Rewrites the ? or $n positional parameters of the query into the parameters of the dialect.
+// Numbered parameters rewritten into unnumbered ones (e.g. $2 into ?) have their arguments
+// reordered, and repeated as many times as the parameters are reused.
+func rewritePlaceholders(d Dialect, query string, params []any) (string, []any, error) {
+	numbered := d.Placeholder(1) != d.Placeholder(2)
+
+	var (
+		result strings.Builder
+		args   []any
+
+		count         int
+		foundQuestion bool
+		foundNumbered bool
+
+		quote        rune
+		dollarQuote  []rune
+		lineComment  bool
+		blockComment bool
+	)
+
+	result.Grow(len(query))
+
+	runes := []rune(query)
+	next := func(i int) rune {
+		if i+1 < len(runes) {
+			return runes[i+1]
+		}
+		return 0
+	}
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			result.WriteRune(c)
+
+		case dollarQuote != nil:
+			if c == '$' && i+len(dollarQuote) <= len(runes) && slices.Equal(runes[i:i+len(dollarQuote)], dollarQuote) {
+				result.WriteString(string(dollarQuote))
+				i += len(dollarQuote) - 1
+				dollarQuote = nil
+				continue
+			}
+			result.WriteRune(c)
+
+		case lineComment:
+			if c == '\n' {
+				lineComment = false
+			}
+			result.WriteRune(c)
+
+		case blockComment:
+			if c == '*' && next(i) == '/' {
+				blockComment = false
+				result.WriteRune(c)
+				c = runes[i+1]
+				i++
+			}
+			result.WriteRune(c)
+
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			result.WriteRune(c)
+
+		case c == '-' && next(i) == '-':
+			lineComment = true
+			result.WriteRune(c)
+
+		case c == '/' && next(i) == '*':
+			blockComment = true
+			result.WriteString("/*")
+			i++
+
+		case c == '$' && !unicode.IsDigit(next(i)) && (i == 0 || !isIdentifierRune(runes[i-1])):
+			// The tag of a dollar-quoted string, e.g. $$ or $body$, can't start with a digit,
+			// which would make it a parameter.
+			end := i + 1
+			for end < len(runes) && isIdentifierRune(runes[end]) {
+				end++
+			}
+
+			if end < len(runes) && runes[end] == '$' {
+				dollarQuote = runes[i : end+1]
+				result.WriteString(string(dollarQuote))
+				i = end
+				continue
+			}
+			result.WriteRune(c)
+
+		case c == '?':
+			foundQuestion = true
+			count++
+
+			result.WriteString(d.Placeholder(count))
+
+		case c == '$' && unicode.IsDigit(next(i)):
+			foundNumbered = true
+
+			end := i + 1
+			for end < len(runes) && unicode.IsDigit(runes[end]) {
+				end++
+			}
+
+			n, err := strconv.Atoi(string(runes[i+1 : end]))
+			if err != nil {
+				return "", nil, err
+			}
+
+			if n < 1 || n > len(params) {
+				return "", nil, fmt.Errorf("positional parameter $%d out of range, found %d arguments", n, len(params))
+			}
+
+			count++
+			if numbered {
+				result.WriteString(d.Placeholder(n))
+			} else {
+				result.WriteString(d.Placeholder(count))
+				args = append(args, params[n-1])
+			}
+
+			i = end - 1
+
+		default:
+			result.WriteRune(c)
+		}
+	}
+
+	if foundQuestion && foundNumbered {
+		return "", nil, fmt.Errorf("mixed ? and $n positional parameters")
+	}
+
+	// The arguments of the numbered parameters are only reordered when the dialect uses unnumbered ones.
+	if foundNumbered && !numbered {
+		return result.String(), args, nil
+	}
+
+	return result.String(), params, nil
+}
+
+func isIdentifierRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
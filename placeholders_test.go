@@ -0,0 +1,175 @@
+package tql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_TranslateParams_Portable_Placeholders(t *testing.T) {
+	tests := []struct {
+		name         string
+		dialect      Dialect
+		query        string
+		params       []any
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name:         "question marks to postgres",
+			dialect:      Postgres,
+			query:        "SELECT * FROM foo WHERE id = ? AND name = ?;",
+			params:       []any{"1", "foo"},
+			expected:     "SELECT * FROM foo WHERE id = $1 AND name = $2;",
+			expectedArgs: []any{"1", "foo"},
+		},
+		{
+			name:         "question marks to mysql",
+			dialect:      MySQL,
+			query:        "SELECT * FROM foo WHERE id = ? AND name = ?;",
+			params:       []any{"1", "foo"},
+			expected:     "SELECT * FROM foo WHERE id = ? AND name = ?;",
+			expectedArgs: []any{"1", "foo"},
+		},
+		{
+			name:         "numbered to sqlserver",
+			dialect:      SQLServer,
+			query:        "SELECT * FROM foo WHERE id = $2 AND name = $1;",
+			params:       []any{"foo", "1"},
+			expected:     "SELECT * FROM foo WHERE id = @p2 AND name = @p1;",
+			expectedArgs: []any{"foo", "1"},
+		},
+		{
+			name:         "reused numbered to mysql",
+			dialect:      MySQL,
+			query:        "SELECT * FROM foo WHERE id = $2 OR parent_id = $2 AND name = $1;",
+			params:       []any{"foo", "1"},
+			expected:     "SELECT * FROM foo WHERE id = ? OR parent_id = ? AND name = ?;",
+			expectedArgs: []any{"1", "1", "foo"},
+		},
+		{
+			name:         "literals and comments",
+			dialect:      Postgres,
+			query:        "SELECT '?', \"a?\" FROM foo -- ?\nWHERE /* ? */ id = ?;",
+			params:       []any{"1"},
+			expected:     "SELECT '?', \"a?\" FROM foo -- ?\nWHERE /* ? */ id = $1;",
+			expectedArgs: []any{"1"},
+		},
+		{
+			name:         "escaped quotes",
+			dialect:      MySQL,
+			query:        "SELECT 'it''s $1' FROM foo WHERE id = $1;",
+			params:       []any{"1"},
+			expected:     "SELECT 'it''s $1' FROM foo WHERE id = ?;",
+			expectedArgs: []any{"1"},
+		},
+		{
+			name:         "dollar-quoted strings",
+			dialect:      Postgres,
+			query:        "SELECT $$it's ?$$, $body$ $$ ? $body$ FROM foo WHERE id = ?;",
+			params:       []any{"1"},
+			expected:     "SELECT $$it's ?$$, $body$ $$ ? $body$ FROM foo WHERE id = $1;",
+			expectedArgs: []any{"1"},
+		},
+		{
+			name:         "dollar in identifier",
+			dialect:      MySQL,
+			query:        "SELECT foo$bar FROM foo WHERE id = $1;",
+			params:       []any{"1"},
+			expected:     "SELECT foo$bar FROM foo WHERE id = ?;",
+			expectedArgs: []any{"1"},
+		},
+	}
+
+	SetPortablePlaceholders(true)
+	defer SetPortablePlaceholders(false)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			query, args, err := translateParams(test.dialect, test.query, test.params...)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err.Error())
+			}
+
+			if query != test.expected {
+				t.Fatalf("value '%s' does not equal expected '%s'", query, test.expected)
+			}
+
+			if !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Fatalf("value '%v' does not equal expected '%v'", args, test.expectedArgs)
+			}
+		})
+	}
+}
+
+func Test_TranslateParams_Portable_Placeholders_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		params []any
+	}{
+		{name: "mixed", query: "SELECT * FROM foo WHERE id = ? AND name = $2;", params: []any{"1", "foo"}},
+		{name: "out of range", query: "SELECT * FROM foo WHERE id = $2;", params: []any{"1"}},
+		{name: "zero", query: "SELECT * FROM foo WHERE id = $0;", params: []any{"1"}},
+	}
+
+	SetPortablePlaceholders(true)
+	defer SetPortablePlaceholders(false)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			_, _, err := translateParams(MySQL, test.query, test.params...)
+
+			// Assert
+			if err == nil {
+				t.Fatalf("expected error, found nil")
+			}
+		})
+	}
+}
+
+func Test_TranslateParams_Portable_Placeholders_Mixed_With_Named(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+	}{
+		{name: "question mark on postgres", dialect: Postgres, query: "SELECT * FROM foo WHERE id = ? AND name = :name;"},
+		{name: "numbered on mysql", dialect: MySQL, query: "SELECT * FROM foo WHERE id = $1 AND name = :name;"},
+	}
+
+	SetPortablePlaceholders(true)
+	defer SetPortablePlaceholders(false)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			_, _, err := translateParams(test.dialect, test.query, map[string]any{"name": "foo"})
+
+			// Assert
+			if err == nil {
+				t.Fatalf("expected error, found nil")
+			}
+		})
+	}
+}
+
+func Test_TranslateParams_Portable_Placeholders_Disabled(t *testing.T) {
+	// Arrange
+	const query = "SELECT * FROM foo WHERE id = $1;"
+
+	// Act
+	translated, _, err := translateParams(MySQL, query, "1")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if translated != query {
+		t.Fatalf("value '%s' does not equal expected '%s'", translated, query)
+	}
+}
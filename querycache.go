@@ -0,0 +1,110 @@
+package tql
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+)
+
+// defaultQueryCacheSize
+// The number of translated queries kept by default. Queries are usually constants,
+// so the cache is only evicting when the queries are built dynamically.
+const defaultQueryCacheSize = 1024
+
+// queryCache
+// A least recently used cache of the compiled queries, keyed by the dialect, the query text and
+// whether the placeholders are portable, so the constant queries are scanned only once.
+type queryCache struct {
+	mu       sync.Mutex
+	size     int
+	entries  map[queryCacheKey]*list.Element
+	eviction *list.List
+}
+
+type queryCacheKey struct {
+	dialect  Dialect
+	query    string
+	portable bool
+}
+
+type queryCacheEntry struct {
+	key      queryCacheKey
+	compiled compiledQuery
+}
+
+var compiledQueries = newQueryCache(defaultQueryCacheSize)
+
+func newQueryCache(size int) *queryCache {
+	return &queryCache{
+		size:     size,
+		entries:  make(map[queryCacheKey]*list.Element),
+		eviction: list.New(),
+	}
+}
+
+// SetQueryCacheSize
+// Sets the maximum number of translated queries kept in the cache, evicting the least recently
+// used queries above it. Setting the size to 0 disables the cache. Defaults to 1024.
+func SetQueryCacheSize(size int) {
+	compiledQueries.resize(size)
+}
+
+// compile
+// Returns the compiled query from the cache, compiling and caching it if it is not there.
+func (c *queryCache) compile(d Dialect, query string, portable bool) compiledQuery {
+	// Dialects which can't be used as map keys are never cached.
+	if !reflect.TypeOf(d).Comparable() {
+		return compileQuery(d, query, portable)
+	}
+
+	key := queryCacheKey{dialect: d, query: query, portable: portable}
+
+	c.mu.Lock()
+	if element, found := c.entries[key]; found {
+		c.eviction.MoveToFront(element)
+		c.mu.Unlock()
+		return element.Value.(queryCacheEntry).compiled
+	}
+	c.mu.Unlock()
+
+	compiled := compileQuery(d, query, portable)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size < 1 {
+		return compiled
+	}
+
+	if _, found := c.entries[key]; !found {
+		c.entries[key] = c.eviction.PushFront(queryCacheEntry{key: key, compiled: compiled})
+		c.evict()
+	}
+
+	return compiled
+}
+
+func (c *queryCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.size = size
+	c.evict()
+}
+
+func (c *queryCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.eviction.Len()
+}
+
+// evict
+// Removes the least recently used queries above the size of the cache. The caller must hold the lock.
+func (c *queryCache) evict() {
+	for c.eviction.Len() > max(c.size, 0) {
+		oldest := c.eviction.Back()
+		c.eviction.Remove(oldest)
+		delete(c.entries, oldest.Value.(queryCacheEntry).key)
+	}
+}
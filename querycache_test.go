@@ -0,0 +1,169 @@
+package tql
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// sliceDialect
+// A dialect which can't be used as a map key.
+type sliceDialect struct {
+	Dialect
+	options []string
+}
+
+func Test_QueryCache_Returns_Cached_Query(t *testing.T) {
+	// Arrange
+	cache := newQueryCache(10)
+	const query = "SELECT * FROM foo WHERE id = :id AND name = :name;"
+
+	// Act
+	first := cache.compile(Postgres, query, false)
+	second := cache.compile(Postgres, query, false)
+
+	// Assert
+	if cache.len() != 1 {
+		t.Fatalf("expected len %d found %d", 1, cache.len())
+	}
+
+	if first.sql != second.sql || len(second.names) != 2 {
+		t.Fatalf("value '%v' does not equal expected '%v'", second, first)
+	}
+}
+
+func Test_QueryCache_Is_Keyed_By_Dialect(t *testing.T) {
+	// Arrange
+	cache := newQueryCache(10)
+	const query = "SELECT * FROM foo WHERE id = :id;"
+
+	// Act
+	postgres := cache.compile(Postgres, query, false)
+	mysql := cache.compile(MySQL, query, false)
+
+	// Assert
+	if cache.len() != 2 {
+		t.Fatalf("expected len %d found %d", 2, cache.len())
+	}
+
+	if postgres.sql != "SELECT * FROM foo WHERE id = $1;" {
+		t.Fatalf("unexpected query '%s'", postgres.sql)
+	}
+
+	if mysql.sql != "SELECT * FROM foo WHERE id = ?;" {
+		t.Fatalf("unexpected query '%s'", mysql.sql)
+	}
+}
+
+func Test_QueryCache_Evicts_Least_Recently_Used(t *testing.T) {
+	// Arrange
+	cache := newQueryCache(2)
+
+	cache.compile(Postgres, "SELECT 1;", false)
+	cache.compile(Postgres, "SELECT 2;", false)
+	cache.compile(Postgres, "SELECT 1;", false)
+
+	// Act
+	cache.compile(Postgres, "SELECT 3;", false)
+
+	// Assert
+	if cache.len() != 2 {
+		t.Fatalf("expected len %d found %d", 2, cache.len())
+	}
+
+	if _, found := cache.entries[queryCacheKey{dialect: Postgres, query: "SELECT 2;"}]; found {
+		t.Fatalf("expected the least recently used query to be evicted")
+	}
+
+	if _, found := cache.entries[queryCacheKey{dialect: Postgres, query: "SELECT 1;"}]; !found {
+		t.Fatalf("expected the recently used query to be kept")
+	}
+}
+
+func Test_QueryCache_Remembers_Queries_With_Fragments(t *testing.T) {
+	// Arrange
+	cache := newQueryCache(2)
+	const query = "SELECT * FROM foo WHERE /*? :id */ id = :id /* end */;"
+
+	// Act
+	first := cache.compile(Postgres, query, false)
+	second := cache.compile(Postgres, query, false)
+
+	// Assert
+	if !first.hasFragments || !second.hasFragments {
+		t.Fatalf("expected the query to have fragments")
+	}
+
+	if len(second.names) != 0 {
+		t.Fatalf("expected len %d found %d", 0, len(second.names))
+	}
+
+	if cache.len() != 1 {
+		t.Fatalf("expected len %d found %d", 1, cache.len())
+	}
+}
+
+func Test_QueryCache_Disabled(t *testing.T) {
+	// Arrange
+	cache := newQueryCache(2)
+	cache.compile(Postgres, "SELECT 1;", false)
+
+	// Act
+	cache.resize(0)
+	compiled := cache.compile(Postgres, "SELECT * FROM foo WHERE id = :id;", false)
+
+	// Assert
+	if cache.len() != 0 {
+		t.Fatalf("expected len %d found %d", 0, cache.len())
+	}
+
+	if compiled.sql != "SELECT * FROM foo WHERE id = $1;" {
+		t.Fatalf("unexpected query '%s'", compiled.sql)
+	}
+}
+
+func Test_QueryCache_Skips_Non_Comparable_Dialects(t *testing.T) {
+	// Arrange
+	cache := newQueryCache(2)
+
+	// Act
+	compiled := cache.compile(sliceDialect{Dialect: Postgres}, "SELECT * FROM foo WHERE id = :id;", false)
+
+	// Assert
+	if cache.len() != 0 {
+		t.Fatalf("expected len %d found %d", 0, cache.len())
+	}
+
+	if compiled.sql != "SELECT * FROM foo WHERE id = $1;" {
+		t.Fatalf("unexpected query '%s'", compiled.sql)
+	}
+}
+
+func Test_QueryCache_Concurrent_Use(t *testing.T) {
+	// Arrange
+	cache := newQueryCache(8)
+
+	var wg sync.WaitGroup
+
+	// Act
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range 100 {
+				query := "SELECT * FROM foo WHERE id = :id AND n = " + strconv.Itoa((i+j)%12) + ";"
+				if compiled := cache.compile(Postgres, query, false); len(compiled.names) != 1 {
+					t.Errorf("expected len %d found %d", 1, len(compiled.names))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// Assert
+	if cache.len() != 8 {
+		t.Fatalf("expected len %d found %d", 8, cache.len())
+	}
+}
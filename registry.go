@@ -0,0 +1,252 @@
+package tql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var ErrQueryNotFound = errors.New("sql: named query not found in registry")
+
+// queryHeader matches the line starting a named query, e.g. -- name: GetUser
+var queryHeader = regexp.MustCompile(`^\s*--\s*name:\s*(\S*)\s*$`)
+
+// Registry
+// Holds the named queries loaded from .sql files.
+//
+// Every query in a file starts with a header comment containing the query name,
+// and lasts until the next header or the end of the file:
+//
+//	-- name: GetUser
+//	SELECT * FROM users WHERE id = :id;
+//
+//	-- name: DeleteUser
+//	DELETE FROM users WHERE id = :id;
+type Registry struct {
+	queries map[string]registeredQuery
+	dialect Dialect
+}
+
+type registeredQuery struct {
+	file     string
+	line     int
+	sql      string
+	compiled *compiledQuery
+}
+
+// LoadRegistry
+// Parses the named queries from all the files in fsys matching the patterns (as used by fs.Glob),
+// e.g. LoadRegistry(tql.Postgres, queries, "queries/*.sql") for an embed.FS. If no patterns are provided,
+// all the .sql files in the root of fsys are loaded.
+//
+// The names of the queries must be unique across all the files. The named parameters of the
+// queries are translated once, using the dialect d (e.g. the one returned by DialectOf). Queries
+// executed using a handle of another dialect are translated when executed.
+func LoadRegistry(d Dialect, fsys fs.FS, patterns ...string) (*Registry, error) {
+	if d == nil {
+		return nil, errors.New("tql: the dialect of the registry is required")
+	}
+
+	if len(patterns) == 0 {
+		patterns = []string{"*.sql"}
+	}
+
+	r := Registry{queries: make(map[string]registeredQuery), dialect: d}
+
+	for _, pattern := range patterns {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			content, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := r.parse(file, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &r, nil
+}
+
+func (r *Registry) parse(file string, content []byte) error {
+	var (
+		name   string
+		line   int
+		body   strings.Builder
+		lineNo int
+	)
+
+	add := func() error {
+		if name == "" {
+			return nil
+		}
+
+		query := strings.TrimSpace(body.String())
+		if query == "" {
+			return fmt.Errorf("%s:%d: missing query for '%s'", file, line, name)
+		}
+
+		if existing, found := r.queries[name]; found {
+			return fmt.Errorf(
+				"%s:%d: duplicate query name '%s', first defined at %s:%d",
+				file,
+				line,
+				name,
+				existing.file,
+				existing.line,
+			)
+		}
+
+		registered := registeredQuery{file: file, line: line, sql: query}
+
+		// Queries with optional fragments depend on the parameter values, and have to be
+		// translated when they are executed.
+		if !hasFragments(query) {
+			compiled := compileQuery(r.dialect, query, portablePlaceholders.Load())
+			registered.compiled = &compiled
+		}
+
+		r.queries[name] = registered
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		lineNo++
+		text := scanner.Text()
+
+		if match := queryHeader.FindStringSubmatch(text); match != nil {
+			if err := add(); err != nil {
+				return err
+			}
+
+			if match[1] == "" {
+				return fmt.Errorf("%s:%d: missing query name", file, lineNo)
+			}
+
+			name = match[1]
+			line = lineNo
+			body.Reset()
+			continue
+		}
+
+		if name == "" {
+			trimmed := strings.TrimSpace(text)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return fmt.Errorf("%s:%d: query is missing the '-- name:' header", file, lineNo)
+			}
+			continue
+		}
+
+		body.WriteString(text)
+		body.WriteRune('\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return add()
+}
+
+// SQL
+// Returns the untranslated SQL of the named query.
+func (r *Registry) SQL(name string) (string, error) {
+	q, found := r.queries[name]
+	if !found {
+		return "", fmt.Errorf("%w: %s", ErrQueryNotFound, name)
+	}
+
+	return q.sql, nil
+}
+
+// Names
+// Returns the names of all the queries in the registry.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.queries))
+	for name := range r.queries {
+		names = append(names, name)
+	}
+	return names
+}
+
+// translate
+// Binds the parameters to the pre-translated query, or translates the query
+// if it could not be translated ahead of time.
+func (r *Registry) translate(d Dialect, name string, params ...any) (string, []any, error) {
+	q, found := r.queries[name]
+	if !found {
+		return "", nil, fmt.Errorf("%w: %s", ErrQueryNotFound, name)
+	}
+
+	// Comparing dialects which are not comparable panics, so they are always translated again.
+	if q.compiled == nil ||
+		!reflect.TypeOf(d).Comparable() ||
+		r.dialect != d ||
+		q.compiled.portable != portablePlaceholders.Load() {
+		return translateParams(d, q.sql, params...)
+	}
+
+	parameters, err := mapParameters(params...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args, err := q.compiled.bind(parameters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(args) < 1 {
+		return positionalArgs(d, q.compiled.sql, params)
+	}
+
+	return q.compiled.sql, args, nil
+}
+
+// QueryNamed
+// Queries the database using the named query from the registry. Behaves the same as tql.Query.
+func QueryNamed[T any](ctx context.Context, q Querier, r *Registry, name string, params ...any) ([]T, error) {
+	result := make([]T, 0, 256)
+
+	d, err := dialectOf(q)
+	if err != nil {
+		return result, err
+	}
+
+	query, args, err := r.translate(d, name, params...)
+	if err != nil {
+		return result, err
+	}
+
+	return queryTranslated(ctx, q, result, query, args)
+}
+
+// ExecNamed
+// Executes the named statement from the registry. Behaves the same as tql.Exec.
+func ExecNamed(ctx context.Context, e Executor, r *Registry, name string, params ...any) (sql.Result, error) {
+	d, err := dialectOf(e)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := r.translate(d, name, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,191 @@
+package tql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_Postgres_QueryNamed(t *testing.T) {
+	// Arrange
+	err := SetActiveDriver("postgres")
+	if err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	fsys := fstest.MapFS{
+		"queries/users.sql": {Data: []byte(`
+-- Queries for the users table.
+
+-- name: GetUser
+SELECT id, value FROM users
+WHERE id = :id AND value = :value;
+
+-- name: DeleteUser
+DELETE FROM users WHERE id = $1;
+`)},
+	}
+
+	registry, err := LoadRegistry(Postgres, fsys, "queries/*.sql")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	db, c := newFakeDB(fakeResultSet{
+		columns: []string{"id", "value"},
+		rows:    [][]driver.Value{{"1", "foo"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	type user struct {
+		ID    string `db:"id"`
+		Value string `db:"value"`
+	}
+
+	// Act
+	users, err := QueryNamed[user](context.Background(), db, registry, "GetUser", map[string]any{"id": "1", "value": "foo"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(users) != 1 {
+		t.Fatalf("expected len %d found %d", 1, len(users))
+	}
+
+	const expectedQuery = "SELECT id, value FROM users\nWHERE id = $1 AND value = $2;"
+	if c.query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", c.query, expectedQuery)
+	}
+
+	if _, err = ExecNamed(context.Background(), db, registry, "DeleteUser", "1"); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(c.args) != 1 || c.args[0].Value != "1" {
+		t.Fatalf("unexpected args '%v'", c.args)
+	}
+
+	_, err = QueryNamed[user](context.Background(), db, registry, "GetUsers")
+	if !errors.Is(err, ErrQueryNotFound) {
+		t.Fatalf("expected '%v' found '%v'", ErrQueryNotFound, err)
+	}
+}
+
+func Test_LoadRegistry_Errors(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		expected string
+	}{
+		{
+			name: "duplicate name",
+			fsys: fstest.MapFS{
+				"a.sql": {Data: []byte("-- name: GetUser\nSELECT 1;\n")},
+				"b.sql": {Data: []byte("\n-- name: GetUser\nSELECT 2;\n")},
+			},
+			expected: "b.sql:2: duplicate query name 'GetUser', first defined at a.sql:1",
+		},
+		{
+			name: "missing query",
+			fsys: fstest.MapFS{
+				"a.sql": {Data: []byte("-- name: GetUser\nSELECT 1;\n\n-- name: GetUsers\n\n")},
+			},
+			expected: "a.sql:4: missing query for 'GetUsers'",
+		},
+		{
+			name: "missing header",
+			fsys: fstest.MapFS{
+				"a.sql": {Data: []byte("-- users\nSELECT 1;\n")},
+			},
+			expected: "a.sql:2: query is missing the '-- name:' header",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			_, err := LoadRegistry(Postgres, test.fsys)
+
+			// Assert
+			if err == nil {
+				t.Fatalf("expected error, found nil")
+			}
+
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("value '%s' does not contain expected '%s'", err.Error(), test.expected)
+			}
+		})
+	}
+}
+
+func Test_LoadRegistry_Translates_Queries_Without_Active_Driver(t *testing.T) {
+	// Arrange
+	previous := activeDriver
+	activeDriver = ""
+	defer func() { activeDriver = previous }()
+
+	fsys := fstest.MapFS{"users.sql": {Data: []byte("-- name: GetUser\nSELECT * FROM users WHERE id = :id;\n")}}
+
+	// Act
+	registry, err := LoadRegistry(MySQL, fsys)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	compiled := registry.queries["GetUser"].compiled
+	if compiled == nil {
+		t.Fatalf("expected the query to be translated when loaded")
+	}
+
+	const expectedQuery = "SELECT * FROM users WHERE id = ?;"
+	if compiled.sql != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", compiled.sql, expectedQuery)
+	}
+}
+
+func Test_LoadRegistry_Requires_Dialect(t *testing.T) {
+	// Act
+	_, err := LoadRegistry(nil, fstest.MapFS{})
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_Registry_Translates_Queries_For_Non_Comparable_Dialects(t *testing.T) {
+	// Arrange
+	d := sliceDialect{Dialect: Postgres}
+
+	fsys := fstest.MapFS{"users.sql": {Data: []byte("-- name: GetUser\nSELECT * FROM users WHERE id = :id;\n")}}
+
+	registry, err := LoadRegistry(d, fsys)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	// Act
+	query, args, err := registry.translate(d, "GetUser", map[string]any{"id": "1"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	const expectedQuery = "SELECT * FROM users WHERE id = $1;"
+	if query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", query, expectedQuery)
+	}
+
+	if len(args) != 1 || args[0] != "1" {
+		t.Fatalf("value '%v' does not equal expected '%v'", args, []any{"1"})
+	}
+}
@@ -0,0 +1,199 @@
+package tql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scanPlan
+// The fields of a struct type the columns of a result set are scanned into. A plan is resolved once
+// for every combination of a type and a list of columns, and reused for all the rows.
+type scanPlan struct {
+	// fields holds the index path (as used by reflect.Value.FieldByIndex) of the field of every column.
+	fields [][]int
+}
+
+type scanPlanKey struct {
+	typ     reflect.Type
+	columns string
+}
+
+var scanPlans sync.Map // map[scanPlanKey]*scanPlan
+
+// scanPlanFor
+// Returns the cached scan plan of the struct type and the columns, resolving it if it is not cached.
+func scanPlanFor(typ reflect.Type, columns []string) (*scanPlan, error) {
+	// The column names can't contain a NUL, so joining them with it keeps the keys unique.
+	key := scanPlanKey{typ: typ, columns: strings.Join(columns, "\x00")}
+
+	if plan, found := scanPlans.Load(key); found {
+		return plan.(*scanPlan), nil
+	}
+
+	paths := fieldPaths(typ, nil)
+
+	plan := scanPlan{fields: make([][]int, len(columns))}
+	for i, c := range columns {
+		path, found := paths[c]
+		if !found {
+			return nil, fmt.Errorf("no matching field found for column: %s", c)
+		}
+
+		plan.fields[i] = path
+	}
+
+	actual, _ := scanPlans.LoadOrStore(key, &plan)
+	return actual.(*scanPlan), nil
+}
+
+// fieldPaths
+// Maps the 'db' tag names of the fields of the struct type to their index paths. The fields of
+// untagged embedded structs are included as if they were the fields of the outer struct, unless
+// the outer struct has a field with the same name.
+func fieldPaths(typ reflect.Type, parent []int) map[string][]int {
+	paths := make(map[string][]int, typ.NumField())
+
+	var embedded []map[string][]int
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		path := append(slices.Clip(parent), i)
+
+		tag, foundTag := field.Tag.Lookup("db")
+		if !foundTag {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				embedded = append(embedded, fieldPaths(field.Type, path))
+			}
+			continue
+		}
+
+		name, opts := parseTag(tag)
+		if slices.Contains(opts, tagOptionMany) {
+			continue
+		}
+
+		paths[name] = path
+	}
+
+	for _, fields := range embedded {
+		for name, path := range fields {
+			if _, found := paths[name]; !found {
+				paths[name] = path
+			}
+		}
+	}
+
+	return paths
+}
+
+// bind
+// Fills dest with the pointers to the fields of the addressable struct value the columns are scanned into.
+func (p *scanPlan) bind(value reflect.Value, dest []any) {
+	for i, path := range p.fields {
+		var field reflect.Value
+		if len(path) == 1 {
+			field = value.Field(path[0])
+		} else {
+			field = value.FieldByIndex(path)
+		}
+
+		dest[i] = field.Addr().Interface()
+	}
+}
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// mappedStruct
+// Returns the struct type the columns are mapped to if typ is a struct or a pointer to a struct,
+// and whether typ is the pointer. Structs scanned as a single value, such as time.Time and
+// the implementations of sql.Scanner, are not mapped.
+func mappedStruct(typ reflect.Type) (reflect.Type, bool, bool) {
+	pointer := typ.Kind() == reflect.Pointer
+	if pointer {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct || typ == timeType || reflect.PointerTo(typ).Implements(scannerType) {
+		return nil, false, false
+	}
+
+	return typ, pointer, true
+}
+
+// structScanner
+// Scans the rows of a result set into structs, or pointers to structs, using the scan plan of
+// the columns of the result set. The destinations are reused for every row.
+type structScanner struct {
+	plan       *scanPlan
+	structType reflect.Type
+	pointer    bool
+	dest       []any
+}
+
+func newStructScanner(rows *sql.Rows, structType reflect.Type, pointer bool) (*structScanner, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	return structScannerFor(cols, structType, pointer)
+}
+
+// structScannerFor
+// Returns the scanner of the columns, which don't have to be all the columns of the result set.
+func structScannerFor(cols []string, structType reflect.Type, pointer bool) (*structScanner, error) {
+	plan, err := scanPlanFor(structType, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	return &structScanner{plan: plan, structType: structType, pointer: pointer, dest: make([]any, len(cols))}, nil
+}
+
+// scan
+// Scans the current row into target, the addressable value of the struct or the pointer to it.
+func (s *structScanner) scan(rows *sql.Rows, target reflect.Value) error {
+	return rows.Scan(s.bind(target)...)
+}
+
+// bind
+// Returns the destinations of the columns in target. Pointers are set to a newly allocated struct.
+func (s *structScanner) bind(target reflect.Value) []any {
+	if s.pointer {
+		target.Set(reflect.New(s.structType))
+		target = target.Elem()
+	}
+
+	s.plan.bind(target, s.dest)
+	return s.dest
+}
+
+// scanStructRows
+// Scans all the remaining rows of the current result set into result. The columns are resolved into
+// a scan plan once, and the destinations are reused for every row.
+func scanStructRows[T any](rows *sql.Rows, result []T, structType reflect.Type, pointer bool) ([]T, error) {
+	scanner, err := newStructScanner(rows, structType, pointer)
+	if err != nil {
+		return result, err
+	}
+
+	for rows.Next() {
+		var zero T
+		result = append(result, zero)
+
+		// The row is scanned directly into its place in the result.
+		if err := scanner.scan(rows, reflect.ValueOf(&result[len(result)-1]).Elem()); err != nil {
+			return result[:len(result)-1], err
+		}
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,152 @@
+package tql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type base struct {
+	ID      string `db:"id"`
+	Created string `db:"created"`
+}
+
+type withBase struct {
+	base
+	Name    string `db:"name"`
+	Created string `db:"created_override"`
+}
+
+func Test_ScanPlanFor_Caches_Plan(t *testing.T) {
+	// Arrange
+	type foo struct {
+		ID   string `db:"id"`
+		Name string `db:"name"`
+	}
+
+	columns := []string{"name", "id"}
+
+	// Act
+	first, err := scanPlanFor(reflect.TypeFor[foo](), columns)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	second, err := scanPlanFor(reflect.TypeFor[foo](), []string{"name", "id"})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	// Assert
+	if first != second {
+		t.Fatalf("expected the cached plan to be returned")
+	}
+
+	expected := [][]int{{1}, {0}}
+	if !reflect.DeepEqual(first.fields, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", first.fields, expected)
+	}
+}
+
+func Test_ScanPlanFor_Embedded_Struct(t *testing.T) {
+	// Act
+	plan, err := scanPlanFor(reflect.TypeFor[withBase](), []string{"id", "name", "created"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expected := [][]int{{0, 0}, {1}, {0, 1}}
+	if !reflect.DeepEqual(plan.fields, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", plan.fields, expected)
+	}
+}
+
+func Test_ScanPlanFor_Missing_Column(t *testing.T) {
+	// Act
+	_, err := scanPlanFor(reflect.TypeFor[withBase](), []string{"id", "missing"})
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_Query_Scans_Every_Row_Into_Its_Own_Struct(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name", "created"},
+		rows: [][]driver.Value{
+			{"1", "foo", "2024-01-01"},
+			{"2", "bar", "2024-01-02"},
+			{"3", "baz", "2024-01-03"},
+		},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	results, err := Query[withBase](context.Background(), db, "SELECT id, name, created FROM foo;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expected := []withBase{
+		{base: base{ID: "1", Created: "2024-01-01"}, Name: "foo"},
+		{base: base{ID: "2", Created: "2024-01-02"}, Name: "bar"},
+		{base: base{ID: "3", Created: "2024-01-03"}, Name: "baz"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", results, expected)
+	}
+}
+
+func Benchmark_Query_Wide_Struct(b *testing.B) {
+	b.StopTimer()
+	if err := SetActiveDriver("postgres"); err != nil {
+		b.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	type wide struct {
+		C0 int64 `db:"c0"`
+		C1 int64 `db:"c1"`
+		C2 int64 `db:"c2"`
+		C3 int64 `db:"c3"`
+		C4 int64 `db:"c4"`
+		C5 int64 `db:"c5"`
+		C6 int64 `db:"c6"`
+		C7 int64 `db:"c7"`
+		C8 int64 `db:"c8"`
+		C9 int64 `db:"c9"`
+	}
+
+	var resultSet fakeResultSet
+	for i := range 10 {
+		resultSet.columns = append(resultSet.columns, "c"+strconv.Itoa(i))
+	}
+
+	for i := range 1000 {
+		row := make([]driver.Value, 10)
+		for j := range row {
+			row[j] = int64(i * j)
+		}
+		resultSet.rows = append(resultSet.rows, row)
+	}
+
+	db, _ := newFakeDB(resultSet)
+	defer func() { _ = db.Close() }()
+
+	b.ReportAllocs()
+	b.StartTimer()
+	for range b.N {
+		_, _ = Query[wide](context.Background(), db, "SELECT * FROM wide;")
+	}
+}
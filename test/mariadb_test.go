@@ -593,3 +593,42 @@ func Test_MariaDB_Exec_Mixed_Named_Positional(t *testing.T) {
 	require.ErrorIs(t, err, sql.ErrNoRows)
 	require.Empty(t, r)
 }
+
+func Test_MariaDB_QueryMulti(t *testing.T) {
+	// Arrange
+	require.NoError(t, tql.SetActiveDriver("mysql"))
+
+	id := uuid.NewString()
+	nullable := uuid.NewString()
+
+	_, err := mariaDB.Exec("INSERT INTO test (id, nullable) VALUES (?, ?);", id, nullable)
+	require.NoError(t, err)
+
+	_, err = mariaDB.Exec("DROP PROCEDURE IF EXISTS test_multi;")
+	require.NoError(t, err)
+
+	_, err = mariaDB.Exec(`
+		CREATE PROCEDURE test_multi(IN test_id text)
+		BEGIN
+			SELECT id, nullable FROM test WHERE id = test_id;
+			SELECT count(*) FROM test WHERE id = test_id;
+		END;`)
+	require.NoError(t, err)
+
+	// Act
+	reader, err := tql.QueryMulti(context.Background(), mariaDB, "CALL test_multi(:id);", map[string]any{"id": id})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, reader.Close()) }()
+
+	results, resultsErr := tql.Next[result](reader)
+	counts, countsErr := tql.Next[int](reader)
+
+	// Assert
+	require.NoError(t, resultsErr)
+	require.Len(t, results, 1)
+	require.Equal(t, id, results[0].ID)
+	require.Equal(t, nullable, *results[0].Nullable)
+
+	require.NoError(t, countsErr)
+	require.Equal(t, []int{1}, counts)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/emanuel-skrenkovic/tql"
+	"github.com/emanuel-skrenkovic/tql/migrate"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sqlite3_Migrate(t *testing.T) {
+	// Arrange
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	// Every connection to an in-memory database gets a database of its own.
+	db.SetMaxOpenConns(1)
+
+	fsys := fstest.MapFS{
+		"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text);")},
+		"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+		"2.create_bar.up.sql":   {Data: []byte("CREATE TABLE bar (id text);")},
+		"2.create_bar.down.sql": {Data: []byte("DROP TABLE bar;")},
+	}
+
+	m, err := migrate.New(db, migrate.SQLite, fsys)
+	require.NoError(t, err)
+
+	// Act
+	upErr := m.Up(context.Background())
+	statusAfterUp, statusAfterUpErr := m.Status(context.Background())
+
+	downErr := m.Down(context.Background(), 1)
+	statusAfterDown, statusAfterDownErr := m.Status(context.Background())
+
+	// Assert
+	require.NoError(t, upErr)
+	require.NoError(t, statusAfterUpErr)
+	require.Equal(t, []migrate.MigrationStatus{
+		{Version: 1, Name: "create_foo", Applied: true},
+		{Version: 2, Name: "create_bar", Applied: true},
+	}, statusAfterUp)
+
+	require.NoError(t, downErr)
+	require.NoError(t, statusAfterDownErr)
+	require.Equal(t, []migrate.MigrationStatus{
+		{Version: 1, Name: "create_foo", Applied: true},
+		{Version: 2, Name: "create_bar", Applied: false},
+	}, statusAfterDown)
+
+	_, err = db.Exec("SELECT * FROM bar;")
+	require.Error(t, err)
+}
+
+func Test_Sqlite3_Migrate_Times_Out_Waiting_For_Lock(t *testing.T) {
+	// Arrange
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	db.SetMaxOpenConns(1)
+
+	fsys := fstest.MapFS{
+		"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text);")},
+		"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+	}
+
+	m, err := migrate.New(db, migrate.SQLite, fsys, migrate.WithLockTimeout(200*time.Millisecond))
+	require.NoError(t, err)
+
+	// Another instance is holding the lock.
+	_, err = db.Exec(`
+		CREATE TABLE schema_migration_lock (id integer PRIMARY KEY, locked_at text NOT NULL);
+		INSERT INTO schema_migration_lock VALUES (1, ?);`,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	require.NoError(t, err)
+
+	// Act
+	err = m.Up(context.Background())
+
+	// Assert
+	require.ErrorIs(t, err, migrate.ErrLockTimeout)
+
+	statuses, err := m.Status(context.Background())
+	require.NoError(t, err)
+	require.False(t, statuses[0].Applied)
+}
+
+func Test_Sqlite3_Migrate_Takes_Over_Stale_Lock(t *testing.T) {
+	// Arrange
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	db.SetMaxOpenConns(1)
+
+	fsys := fstest.MapFS{
+		"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text);")},
+		"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+	}
+
+	m, err := migrate.New(
+		db,
+		migrate.SQLite,
+		fsys,
+		migrate.WithLockTimeout(time.Second),
+		migrate.WithStaleLockTimeout(time.Minute),
+	)
+	require.NoError(t, err)
+
+	// An instance which crashed left the lock behind.
+	_, err = db.Exec(`
+		CREATE TABLE schema_migration_lock (id integer PRIMARY KEY, locked_at text NOT NULL);
+		INSERT INTO schema_migration_lock VALUES (1, ?);`,
+		time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	)
+	require.NoError(t, err)
+
+	// Act
+	err = m.Up(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+
+	statuses, err := m.Status(context.Background())
+	require.NoError(t, err)
+	require.True(t, statuses[0].Applied)
+
+	var locks int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migration_lock;").Scan(&locks))
+	require.Equal(t, 0, locks)
+}
+
+func Test_Sqlite3_Migrate_Go_And_Repeatable_Migrations(t *testing.T) {
+	// Arrange
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	db.SetMaxOpenConns(1)
+
+	fsys := fstest.MapFS{
+		"1.create_foo.up.sql":   {Data: []byte("CREATE TABLE foo (id text, value text);")},
+		"1.create_foo.down.sql": {Data: []byte("DROP TABLE foo;")},
+		"R.foo_view.sql": {Data: []byte(`
+			DROP VIEW IF EXISTS foo_view;
+			CREATE VIEW foo_view AS SELECT id FROM foo;`)},
+	}
+
+	backfill := migrate.WithGoMigration(
+		2,
+		"backfill_foo",
+		func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO foo (id, value) VALUES ('1', 'backfilled');")
+			return err
+		},
+		func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM foo WHERE value = 'backfilled';")
+			return err
+		},
+	)
+
+	m, err := migrate.New(db, migrate.SQLite, fsys, backfill)
+	require.NoError(t, err)
+
+	// Act
+	upErr := m.Up(context.Background())
+	statusAfterUp, statusAfterUpErr := m.Status(context.Background())
+
+	// The view changes, so the next Up reapplies it.
+	fsys["R.foo_view.sql"] = &fstest.MapFile{Data: []byte(`
+		DROP VIEW IF EXISTS foo_view;
+		CREATE VIEW foo_view AS SELECT id, value FROM foo;`)}
+
+	changed, err := migrate.New(db, migrate.SQLite, fsys, backfill)
+	require.NoError(t, err)
+
+	statusBeforeReapply, statusBeforeReapplyErr := changed.Status(context.Background())
+	reapplyErr := changed.Up(context.Background())
+
+	// Assert
+	require.NoError(t, upErr)
+	require.NoError(t, statusAfterUpErr)
+	require.Equal(t, []migrate.MigrationStatus{
+		{Version: 1, Name: "create_foo", Applied: true},
+		{Version: 2, Name: "backfill_foo", Applied: true},
+		{Name: "foo_view", Applied: true, Repeatable: true},
+	}, statusAfterUp)
+
+	require.NoError(t, statusBeforeReapplyErr)
+	require.Equal(t, migrate.MigrationStatus{
+		Name:       "foo_view",
+		Applied:    true,
+		Changed:    true,
+		Repeatable: true,
+	}, statusBeforeReapply[2])
+
+	require.NoError(t, reapplyErr)
+
+	value, err := tql.QuerySingle[string](context.Background(), db, "SELECT value FROM foo_view;")
+	require.NoError(t, err)
+	require.Equal(t, "backfilled", value)
+}
@@ -487,6 +487,62 @@ func Test_Sqlite3_Query_Basic_Type_Pointer_Null(t *testing.T) {
 	require.Nil(t, r)
 }
 
+func Test_Sqlite3_Query_Struct_Pointer(t *testing.T) {
+	// Arrange
+	require.NoError(t, tql.SetActiveDriver("sqlite3"))
+
+	_, err := sqlite3DB.Exec("DELETE FROM test;")
+	require.NoError(t, err)
+
+	id := uuid.NewString()
+	nullable := uuid.NewString()
+
+	_, err = sqlite3DB.Exec("INSERT INTO test (id, nullable) VALUES (?, ?), (?, NULL);", id, nullable, uuid.NewString())
+	require.NoError(t, err)
+
+	// Act
+	r, err := tql.Query[*result](context.Background(), sqlite3DB, "SELECT id, nullable FROM test ORDER BY nullable DESC;")
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, r, 2)
+	require.Equal(t, &result{ID: id, Nullable: &nullable}, r[0])
+	require.Nil(t, r[1].Nullable)
+}
+
+func Test_Sqlite3_QueryFirstOrDefault_Struct_Pointer_Returns_Nil_Default(t *testing.T) {
+	// Arrange
+	require.NoError(t, tql.SetActiveDriver("sqlite3"))
+
+	// Act
+	r, err := tql.QueryFirstOrDefault[*result](context.Background(), sqlite3DB, nil, "SELECT id, nullable FROM test WHERE id = '';")
+
+	// Assert
+	require.NoError(t, err)
+	require.Nil(t, r)
+}
+
+func Test_Sqlite3_QueryMap(t *testing.T) {
+	// Arrange
+	require.NoError(t, tql.SetActiveDriver("sqlite3"))
+
+	_, err := sqlite3DB.Exec("DELETE FROM test;")
+	require.NoError(t, err)
+
+	id := uuid.NewString()
+	nullable := uuid.NewString()
+
+	_, err = sqlite3DB.Exec("INSERT INTO test (id, nullable) VALUES (?, ?);", id, nullable)
+	require.NoError(t, err)
+
+	// Act
+	r, err := tql.QueryMap[string, result](context.Background(), sqlite3DB, "SELECT id AS key, id, nullable FROM test;")
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, map[string]result{id: {ID: id, Nullable: &nullable}}, r)
+}
+
 func Test_Sqlite3_Query_Empty_Result(t *testing.T) {
 	// Arrange
 	require.NoError(t, tql.SetActiveDriver("sqlite3"))
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/emanuel-skrenkovic/tql"
+	"github.com/emanuel-skrenkovic/tql/tqlreplay"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sqlite3_TqlReplay_Replays_Recorded_Queries(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	recording := filepath.Join(dir, "sqlite3.json")
+
+	run := func(db *sql.DB) []result {
+		ctx := context.Background()
+
+		_, err := tql.Exec(ctx, db, "CREATE TABLE IF NOT EXISTS test (id text, nullable text);")
+		require.NoError(t, err)
+
+		_, err = tql.Exec(ctx, db, "INSERT INTO test (id, nullable) VALUES (:id, :nullable);", result{ID: "1"})
+		require.NoError(t, err)
+
+		results, err := tql.Query[result](ctx, db, "SELECT id, nullable FROM test WHERE id = :id;", map[string]any{"id": "1"})
+		require.NoError(t, err)
+
+		return results
+	}
+
+	recordDB, err := tqlreplay.Open(tqlreplay.ModeRecord, "sqlite3", filepath.Join(dir, "tql.db"), recording)
+	require.NoError(t, err)
+
+	recorded := run(recordDB)
+	require.NoError(t, recordDB.Close())
+
+	// Act
+	replayer, err := tqlreplay.NewReplayer(recording)
+	require.NoError(t, err)
+
+	replayDB := sql.OpenDB(replayer)
+	defer func() { require.NoError(t, replayDB.Close()) }()
+
+	replayed := run(replayDB)
+
+	// Assert
+	require.NoError(t, replayer.Verify())
+	require.Equal(t, tql.SQLite, replayer.Dialect())
+	require.Equal(t, []result{{ID: "1"}}, recorded)
+	require.Equal(t, recorded, replayed)
+}
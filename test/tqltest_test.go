@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/emanuel-skrenkovic/tql"
+	"github.com/emanuel-skrenkovic/tql/tqltest"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sqlite3_TqlTest_Tx_Rolls_Back_After_Test(t *testing.T) {
+	// Arrange
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "tqltest.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	_, err = db.Exec("CREATE TABLE foo (id text);")
+	require.NoError(t, err)
+
+	// Act
+	t.Run("inserts", func(t *testing.T) {
+		tx := tqltest.Tx(t, db)
+
+		_, err := tql.Exec(context.Background(), tx, "INSERT INTO foo (id) VALUES (:id);", map[string]any{"id": "1"})
+		require.NoError(t, err)
+
+		count, err := tql.QuerySingle[int](context.Background(), tx, "SELECT COUNT(*) FROM foo;")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	// Assert
+	count, err := tql.QuerySingle[int](context.Background(), db, "SELECT COUNT(*) FROM foo;")
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+}
+
+func Test_Sqlite3_TqlTest_Shared_Rolls_Back_Savepoints(t *testing.T) {
+	// Arrange
+	// Every connection to an in-memory database gets a database of its own, so the schema
+	// is created through the shared connection.
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	shared, err := tqltest.NewShared(context.Background(), db)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, shared.Close()) }()
+
+	_, err = shared.ExecContext(context.Background(), "CREATE TABLE foo (id text);")
+	require.NoError(t, err)
+
+	_, err = shared.ExecContext(context.Background(), "INSERT INTO foo (id) VALUES ('seed');")
+	require.NoError(t, err)
+
+	// Act
+	for _, id := range []string{"1", "2"} {
+		t.Run("inserts "+id, func(t *testing.T) {
+			sp := shared.Savepoint(t)
+
+			_, err := tql.Exec(context.Background(), sp, "INSERT INTO foo (id) VALUES (:id);", map[string]any{"id": id})
+			require.NoError(t, err)
+
+			ids, err := tql.Query[string](context.Background(), sp, "SELECT id FROM foo ORDER BY id;")
+			require.NoError(t, err)
+			require.Equal(t, []string{id, "seed"}, ids)
+		})
+	}
+
+	// Assert
+	ids, err := tql.Query[string](context.Background(), shared, "SELECT id FROM foo;")
+	require.NoError(t, err)
+	require.Equal(t, []string{"seed"}, ids)
+}
@@ -7,21 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 var ErrMultipleResults = errors.New("sql: found multiple results expected single")
 
-type typeMapper struct {
-	typeFieldCache map[string]map[string]int
-}
-
-var mapper = typeMapper{
-	typeFieldCache: make(map[string]map[string]int),
-}
-
 // SetActiveDriver
 //
 // *Do not use this!*
@@ -30,6 +22,9 @@ var mapper = typeMapper{
 // Don't use this, it's global state, it's not safe for concurrent use, and it is bad.
 // It is just here, so I can choose which driver I want to use in the tests for tql,
 // and the tests are in a separate module so this is public.
+//
+// The dialect of a *sql.DB is detected from its driver, the active driver is only used for the
+// handles which do not expose their driver (e.g. *sql.Tx) when multiple drivers are registered.
 func SetActiveDriver(driver string) error {
 	for _, d := range sql.Drivers() {
 		if d == driver {
@@ -112,7 +107,12 @@ func QueryFirstOrDefault[T any](ctx context.Context, q Querier, def T, query str
 // the function returns sql.ErrNoRows.
 func QueryFirst[T any](ctx context.Context, q Querier, query string, params ...any) (T, error) {
 	var result T
-	parameterisedQuery, args, err := translateParams(query, params...)
+	d, err := dialectOf(q)
+	if err != nil {
+		return result, err
+	}
+
+	parameterisedQuery, args, err := translateParams(d, query, params...)
 	if err != nil {
 		return result, err
 	}
@@ -142,26 +142,20 @@ func QueryFirst[T any](ctx context.Context, q Querier, query string, params ...a
 		return result, err
 	}
 
-	val := reflect.Indirect(reflect.ValueOf(result))
-
-	switch val.Kind() {
-	case reflect.Struct:
-		var cols []string
-		cols, err = rows.Columns()
+	if structType, pointer, mapped := mappedStruct(reflect.TypeFor[T]()); mapped {
+		var scanner *structScanner
+		scanner, err = newStructScanner(rows, structType, pointer)
 		if err != nil {
 			return result, err
 		}
 
-		var dest []any
-		dest, err = createDestinations(&result, cols)
-		if err != nil {
-			return result, err
-		}
+		err = scanner.scan(rows, reflect.ValueOf(&result).Elem())
+		return result, err
+	}
 
-		if err = rows.Scan(dest...); err != nil {
-			return result, err
-		}
+	val := reflect.Indirect(reflect.ValueOf(result))
 
+	switch val.Kind() {
 	case reflect.Slice:
 		return result, fmt.Errorf("invalid type: slice")
 
@@ -191,12 +185,23 @@ func Query[T any](ctx context.Context, q Querier, query string, params ...any) (
 	// TODO: think about returning sql.ErrNoRows if no results are found.
 	result := make([]T, 0, 256)
 
-	parameterisedQuery, args, err := translateParams(query, params...)
+	d, err := dialectOf(q)
 	if err != nil {
 		return result, err
 	}
 
-	rows, err := q.QueryContext(ctx, parameterisedQuery, args...)
+	parameterisedQuery, args, err := translateParams(d, query, params...)
+	if err != nil {
+		return result, err
+	}
+
+	return queryTranslated(ctx, q, result, parameterisedQuery, args)
+}
+
+// queryTranslated
+// Queries the database with an already parameterised query and appends all the results to result.
+func queryTranslated[T any](ctx context.Context, q Querier, result []T, query string, args []any) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return result, err
 	}
@@ -215,49 +220,55 @@ func Query[T any](ctx context.Context, q Querier, query string, params ...any) (
 		}
 	}()
 
-	for rows.Next() {
-		var current T
+	return scanRows(rows, result)
+}
 
-		val := reflect.Indirect(reflect.ValueOf(current))
+// scanRows
+// Scans all the remaining rows of the current result set into result.
+func scanRows[T any](rows *sql.Rows, result []T) ([]T, error) {
+	agg, err := typeAggregation(reflect.TypeFor[T]())
+	if err != nil {
+		return result, err
+	}
 
-		switch val.Kind() {
-		case reflect.Struct:
-			var cols []string
-			cols, err = rows.Columns()
-			if err != nil {
-				return result, err
-			}
+	if agg != nil {
+		return scanAggregated(rows, result, agg)
+	}
 
-			var dest []any
-			dest, err = createDestinations(&current, cols)
-			if err != nil {
-				return result, err
-			}
+	if structType, pointer, mapped := mappedStruct(reflect.TypeFor[T]()); mapped {
+		return scanStructRows(rows, result, structType, pointer)
+	}
 
-			if err = rows.Scan(dest...); err != nil {
-				return result, err
-			}
+	for rows.Next() {
+		var current T
+		if err := scanRow(rows, &current); err != nil {
+			return result, err
+		}
 
-		case reflect.Pointer:
-			underlyingType := reflect.TypeOf(current).Elem()
-			zero := reflect.New(underlyingType)
+		result = append(result, current)
+	}
 
-			val.Set(zero)
+	return result, rows.Err()
+}
 
-			if err = rows.Scan(current); err != nil {
-				return result, err
-			}
+// scanRow
+// Scans the current row into dest directly from the single column. Structs are
+// scanned using scanStructRows.
+func scanRow[T any](rows *sql.Rows, dest *T) error {
+	val := reflect.Indirect(reflect.ValueOf(*dest))
 
-		default:
-			if err = rows.Scan(&current); err != nil {
-				return result, err
-			}
-		}
+	switch val.Kind() {
+	case reflect.Pointer:
+		underlyingType := reflect.TypeOf(*dest).Elem()
+		zero := reflect.New(underlyingType)
 
-		result = append(result, current)
-	}
+		val.Set(zero)
 
-	return result, err
+		return rows.Scan(*dest)
+
+	default:
+		return rows.Scan(dest)
+	}
 }
 
 type Executor interface {
@@ -274,7 +285,12 @@ type Executor interface {
 // When using named parameters with structs as params, the names in the query *must* be specified as the
 // db tag in the struct name. When using a map, the keys will be the names.
 func Exec(ctx context.Context, e Executor, query string, params ...any) (sql.Result, error) {
-	parameterisedQuery, args, err := translateParams(query, params...)
+	d, err := dialectOf(e)
+	if err != nil {
+		return nil, err
+	}
+
+	parameterisedQuery, args, err := translateParams(d, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -332,7 +348,7 @@ ParamLoop:
 						return nil, fmt.Errorf("field %s is not tagged with 'db' tag", field.Name)
 					}
 
-					fieldTags[i] = tag
+					fieldTags[i], _ = parseTag(tag)
 					exportedFieldIndices = append(exportedFieldIndices, i)
 				}
 
@@ -353,123 +369,148 @@ ParamLoop:
 
 var activeDriver string
 
+// getActiveDriver
+// Returns the driver set using SetActiveDriver. If it was not set, returns the only registered
+// driver, or an empty string if there are multiple, as picking any of them would be a guess.
 func getActiveDriver() string {
-	if activeDriver == "" {
-		activeDriver = sql.Drivers()[0]
+	if activeDriver != "" {
+		return activeDriver
 	}
 
-	return activeDriver
-}
-
-type indicators struct {
-	named      rune
-	positional rune
-}
-
-var driverIndicators = map[string]indicators{
-	"postgres":         {named: ':', positional: '$'},
-	"pgx":              {named: ':', positional: '$'},
-	"pq-timeouts":      {named: ':', positional: '$'},
-	"cloudsqlpostgres": {named: ':', positional: '$'},
-	"ql":               {named: ':', positional: '$'},
-	"nrpostgres":       {named: ':', positional: '$'},
-	"cockroach":        {named: ':', positional: '$'},
-
-	"mysql":   {named: ':', positional: '?'},
-	"nrmysql": {named: ':', positional: '?'},
+	if drivers := sql.Drivers(); len(drivers) == 1 {
+		return drivers[0]
+	}
 
-	"sqlite3":   {named: ':', positional: '?'},
-	"nrsqlite3": {named: ':', positional: '?'},
+	return ""
 }
 
-func parameterIndicators(driverName string) (rune, rune, error) {
-	i, found := driverIndicators[driverName]
-	if !found {
-		return 0, 0, fmt.Errorf("failed to find driver parameter indicator mapping")
-	}
-	return i.named, i.positional, nil
-}
+// namedParameterIndicator
+// Marks the named parameters in the queries, e.g. :id.
+const namedParameterIndicator = ':'
 
-func translateParams(query string, params ...any) (string, []any, error) {
+func translateParams(d Dialect, query string, params ...any) (string, []any, error) {
 	parameters, err := mapParameters(params...)
 	if err != nil {
 		return "", nil, err
 	}
 
-	// #horribleways
-	driverName := getActiveDriver()
-	pos, nam, err := parameterIndicators(driverName)
-	if err != nil {
-		return "", nil, err
+	portable := portablePlaceholders.Load()
+
+	compiled := compiledQueries.compile(d, query, portable)
+	if compiled.hasFragments {
+		expandedQuery, err := expandFragments(query, parameters)
+		if err != nil {
+			return "", nil, err
+		}
+
+		compiled = compiledQueries.compile(d, expandedQuery, portable)
+
+		// Queries with optional fragments always use named parameters, even
+		// if all the fragments containing them were removed.
+		args, err := compiled.bind(parameters)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return compiled.sql, args, nil
 	}
 
-	parameterisedQuery, args, err := parameteriseQuery(pos, nam, query, parameters)
+	args, err := compiled.bind(parameters)
 	if err != nil {
 		return "", nil, err
 	}
 
 	if len(args) < 1 {
-		args = params
+		return positionalArgs(d, compiled.sql, params)
 	}
 
-	return parameterisedQuery, args, nil
+	return compiled.sql, args, nil
 }
 
-func parameteriseQuery(
-	namedParamIndicator rune,
-	positionalParamIndicator rune,
-	query string,
-	parameters map[string]any,
-) (string, []any, error) {
+func parameteriseQuery(d Dialect, query string, parameters map[string]any) (string, []any, error) {
+	compiled := compiledQueries.compile(d, query, portablePlaceholders.Load())
+
+	args, err := compiled.bind(parameters)
+	if err != nil {
+		return "", []any{}, err
+	}
+
+	return compiled.sql, args, nil
+}
+
+// compiledQuery
+// A query with its named parameters rewritten into positional parameters. Holds the
+// names of the parameters in the order of their positions, so the values can be bound
+// without scanning the query again. Queries with optional fragments are not rewritten,
+// as they depend on the parameters, and only have hasFragments set.
+type compiledQuery struct {
+	sql           string
+	names         []string
+	hasPositional bool
+	hasFragments  bool
+	portable      bool
+}
+
+// compileQuery
+// Rewrites the named parameters of the query into the positional parameters of the dialect.
+// With portable placeholders, the ? and $n parameters count as positional parameters as well,
+// so mixing them with named parameters is rejected.
+func compileQuery(d Dialect, query string, portable bool) compiledQuery {
+	if hasFragments(query) {
+		return compiledQuery{hasFragments: true, portable: portable}
+	}
+
 	var (
 		insideName    bool
 		hasPositional bool
 
-		result     strings.Builder
-		resultArgs = make([]any, 0, len(parameters))
+		result strings.Builder
+		names  []string
 
 		currentName strings.Builder
-		currentNum  int
 	)
 
 	result.Grow(len(query))
 
-	// TODO: inside name has to know the connection type to
-	// properly decide on which token to use as the namedIndicator
-	// of a parameter inside a query.
-	// Also, which token to remap to.
+	marker, numbered := positionalMarker(d)
 
-	for _, c := range query {
-		if !hasPositional && c == positionalParamIndicator {
+	// The positional parameters of some dialects (e.g. :1 in Oracle) start with the same rune
+	// as the named parameters, and are told apart by the number following it.
+	positionalIsNamed := marker == string(namedParameterIndicator)
+
+	writeParameter := func() {
+		names = append(names, currentName.String())
+		insideName = false
+
+		result.WriteString(d.Placeholder(len(names)))
+	}
+
+	for i, c := range query {
+		if !hasPositional && !positionalIsNamed && isPositionalParameter(query[i:], marker, numbered) {
 			hasPositional = true
 		}
 
-		if !insideName && c == namedParamIndicator {
+		if !hasPositional && portable && (isPositionalParameter(query[i:], "?", false) || isPositionalParameter(query[i:], "$", true)) {
+			hasPositional = true
+		}
+
+		if positionalIsNamed && insideName && currentName.Len() == 0 && unicode.IsDigit(c) {
+			hasPositional = true
+			insideName = false
+
+			result.WriteRune(namedParameterIndicator)
+			result.WriteRune(c)
+			continue
+		}
+
+		if !insideName && c == namedParameterIndicator {
 			currentName.Reset()
 			insideName = true
 			continue
 		}
 
 		if insideName && !(unicode.IsLetter(c) || unicode.IsNumber(c) || c == '_') {
-			arg, found := parameters[currentName.String()]
-			if !found {
-				return "", []any{}, fmt.Errorf("query parameter '%s' not found in provided parameters", currentName.String())
-			}
-			resultArgs = append(resultArgs, arg)
-
-			insideName = false
-			currentNum++
-
-			// No need for a default case here (famous last words).
-			// If there is no driver, then the execution will return
-			// an error before this part of code is executed.
-			switch getActiveDriver() {
-			case "mysql", "sqlite3":
-				result.WriteRune(positionalParamIndicator)
-			case "postgres", "pgx":
-				result.WriteRune(positionalParamIndicator)
-				result.WriteString(strconv.FormatInt(int64(currentNum), 10))
-			}
+			writeParameter()
 			result.WriteRune(c)
 			continue
 		}
@@ -482,55 +523,55 @@ func parameteriseQuery(
 		result.WriteRune(c)
 	}
 
-	if hasPositional && len(resultArgs) > 0 {
-		return "", []any{}, fmt.Errorf("mixed positional and named parameters")
+	// The query ends with a named parameter.
+	if insideName {
+		writeParameter()
 	}
 
-	return result.String(), resultArgs, nil
+	return compiledQuery{sql: result.String(), names: names, hasPositional: hasPositional, portable: portable}
 }
 
-// TODO: candidate for caching
-func createDestinations(source any, columns []string) ([]any, error) {
-	value := reflect.ValueOf(source).Elem()
-	valueType := value.Type()
-	typeName := typeName(valueType)
+// positionalMarker
+// Returns the text the positional parameters of the dialect start with, e.g. $ for $1 or @p for @p1,
+// and whether the parameters are numbered.
+func positionalMarker(d Dialect) (string, bool) {
+	first := d.Placeholder(1)
+	return strings.TrimRightFunc(first, unicode.IsDigit), first != d.Placeholder(2)
+}
 
-	indices, found := mapper.typeFieldCache[typeName]
-	if !found {
-		numFields := valueType.NumField()
-		indices = make(map[string]int, numFields)
+// isPositionalParameter
+// Reports whether the query starts with a positional parameter. Numbered parameters need a number
+// following the marker, so e.g. @@ROWCOUNT or a @variable are not mistaken for @p1.
+func isPositionalParameter(query, marker string, numbered bool) bool {
+	if !strings.HasPrefix(query, marker) {
+		return false
+	}
 
-		for i := range numFields {
-			field := valueType.Field(i)
+	if !numbered {
+		return true
+	}
 
-			tag, foundTag := field.Tag.Lookup("db")
-			if !foundTag {
-				continue
-			}
+	next, _ := utf8.DecodeRuneInString(query[len(marker):])
+	return unicode.IsDigit(next)
+}
 
-			indices[tag] = i
+// bind
+// Gathers the values of the query parameters in the order of their positions.
+func (c compiledQuery) bind(parameters map[string]any) ([]any, error) {
+	args := make([]any, 0, len(c.names))
+	for _, name := range c.names {
+		arg, found := parameters[name]
+		if !found {
+			return nil, fmt.Errorf("query parameter '%s' not found in provided parameters", name)
 		}
-
-		mapper.typeFieldCache[typeName] = indices
+		args = append(args, arg)
 	}
 
-	dest := make([]any, len(columns))
-	for i, c := range columns {
-		fieldIdx, foundField := indices[c]
-		if !foundField {
-			return nil, fmt.Errorf("no matching field found for column: %s", c)
-		}
-
-		field := value.Field(fieldIdx)
-		switch field.CanAddr() {
-		case true:
-			dest[i] = field.Addr().Interface()
-		case false:
-			dest[i] = field.Interface()
-		}
+	if c.hasPositional && len(args) > 0 {
+		return nil, fmt.Errorf("mixed positional and named parameters")
 	}
 
-	return dest, nil
+	return args, nil
 }
 
 // typeFieldDBTags
@@ -584,7 +625,7 @@ func bindArgs(params ...any) (map[string]any, error) {
 						return nil, fmt.Errorf("field %s is not tagged with 'db' tag", field.Name)
 					}
 
-					fieldTags[i] = tag
+					fieldTags[i], _ = parseTag(tag)
 				}
 			}
 
@@ -601,3 +642,11 @@ func bindArgs(params ...any) (map[string]any, error) {
 func typeName(typ reflect.Type) string {
 	return strings.Join([]string{typ.PkgPath(), typ.Name()}, "/")
 }
+
+// parseTag
+// Splits a 'db' tag into the column name and the options following it,
+// e.g. `db:"id,pk"` into "id" and ["pk"].
+func parseTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
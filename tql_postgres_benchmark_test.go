@@ -1,10 +1,13 @@
 package tql
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func Benchmark_Postgres_ParameteriseQuery(b *testing.B) {
 	b.StopTimer()
-	n, p, err := parameterIndicators("postgres")
+	d, err := dialectFor("postgres")
 	if err != nil {
 		b.Fatalf("unexpected error: %s", err.Error())
 	}
@@ -21,10 +24,58 @@ func Benchmark_Postgres_ParameteriseQuery(b *testing.B) {
 	const query = "INSERT INTO foo (a, b, c, d) VALUES (:name, :age, :first, :last)"
 	b.StartTimer()
 	for range b.N {
-		_, _, _ = parameteriseQuery(n, p, query, args)
+		_, _, _ = parameteriseQuery(d, query, args)
 	}
 }
 
+func Benchmark_Postgres_ParameteriseQuery_Uncached(b *testing.B) {
+	b.StopTimer()
+	d, err := dialectFor("postgres")
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	type t struct {
+		Name  string `db:"name"`
+		Age   int    `db:"age"`
+		First string `db:"first"`
+		Last  string `db:"last"`
+	}
+	am := t{"Emanuel Skrenkovic", 30, "Emanuel", "Skrenkovic"}
+
+	args, _ := bindArgs(am)
+	const query = "INSERT INTO foo (a, b, c, d) VALUES (:name, :age, :first, :last)"
+	b.StartTimer()
+	for range b.N {
+		_, _ = compileQuery(d, query, false).bind(args)
+	}
+}
+
+func Benchmark_Postgres_ParameteriseQuery_Parallel(b *testing.B) {
+	b.StopTimer()
+	d, err := dialectFor("postgres")
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	type t struct {
+		Name  string `db:"name"`
+		Age   int    `db:"age"`
+		First string `db:"first"`
+		Last  string `db:"last"`
+	}
+	am := t{"Emanuel Skrenkovic", 30, "Emanuel", "Skrenkovic"}
+
+	args, _ := bindArgs(am)
+	const query = "INSERT INTO foo (a, b, c, d) VALUES (:name, :age, :first, :last)"
+	b.StartTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _, _ = parameteriseQuery(d, query, args)
+		}
+	})
+}
+
 func Benchmark_Postgres_bindArgs_Struct(b *testing.B) {
 	b.StopTimer()
 	type t struct {
@@ -41,7 +92,7 @@ func Benchmark_Postgres_bindArgs_Struct(b *testing.B) {
 	}
 }
 
-func Benchmark_Postgres_createDestinations(b *testing.B) {
+func Benchmark_Postgres_scanPlan_bind(b *testing.B) {
 	b.StopTimer()
 	cols := []string{
 		"name",
@@ -58,8 +109,10 @@ func Benchmark_Postgres_createDestinations(b *testing.B) {
 	am := t{"Emanuel Skrenkovic", 30, "Emanuel", "Skrenkovic"}
 
 	b.StartTimer()
+	dest := make([]any, len(cols))
 	for range b.N {
-		_, _ = createDestinations(&am, cols)
+		plan, _ := scanPlanFor(reflect.TypeFor[t](), cols)
+		plan.bind(reflect.ValueOf(&am).Elem(), dest)
 	}
 }
 
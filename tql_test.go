@@ -1,9 +1,11 @@
 package tql
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"io"
 	"testing"
 )
 
@@ -13,8 +15,94 @@ func (d dummyDriver) Open(string) (driver.Conn, error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
+// fakeResultSet
+// A single canned result set returned by the fake connection.
+type fakeResultSet struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeConnector
+// Opens connections which return the canned result sets for every query,
+// and records the last query and arguments they received.
+type fakeConnector struct {
+	resultSets []fakeResultSet
+	query      string
+	args       []driver.NamedValue
+}
+
+func newFakeDB(resultSets ...fakeResultSet) (*sql.DB, *fakeConnector) {
+	c := &fakeConnector{resultSets: resultSets}
+	return sql.OpenDB(c), c
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return dummyDriver{} }
+
+type fakeConn struct{ connector *fakeConnector }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, fmt.Errorf("not implemented") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.connector.query = query
+	c.connector.args = args
+	return &fakeRows{resultSets: c.connector.resultSets}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.connector.query = query
+	c.connector.args = args
+	return driver.RowsAffected(0), nil
+}
+
+type fakeRows struct {
+	resultSets []fakeResultSet
+	set        int
+	row        int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.resultSets) == 0 {
+		return nil
+	}
+	return r.resultSets[r.set].columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.resultSets) == 0 || r.row >= len(r.resultSets[r.set].rows) {
+		return io.EOF
+	}
+
+	copy(dest, r.resultSets[r.set].rows[r.row])
+	r.row++
+	return nil
+}
+
+func (r *fakeRows) HasNextResultSet() bool { return r.set < len(r.resultSets)-1 }
+
+func (r *fakeRows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+
+	r.set++
+	r.row = 0
+	return nil
+}
+
 func TestMain(m *testing.M) {
 	sql.Register("postgres", dummyDriver{})
+	sql.Register("tql_question", questionDriver{})
+	sql.Register("tql_counting", countingDriver{})
 	m.Run()
 }
 
@@ -25,14 +113,14 @@ func Test_Postgres_ParameteriseQuery_Substitution(t *testing.T) {
 		t.Fatalf("failed to set driver: %s", err.Error())
 	}
 
-	n, p, err := parameterIndicators("postgres")
+	d, err := dialectFor("postgres")
 	if err != nil {
 		t.Fatalf("unexpected error: %s", err.Error())
 	}
 
 	const query = "SELECT * FROM tablename WHERE id = :id;"
 	// Act
-	parameterisedQuery, args, err := parameteriseQuery(n, p, query, map[string]any{"id": "123"})
+	parameterisedQuery, args, err := parameteriseQuery(d, query, map[string]any{"id": "123"})
 
 	// Assert
 	if err != nil {
@@ -60,14 +148,14 @@ func Test_Postgres_ParameteriseQuery_Substitution_Multiple_Parameters(t *testing
 		t.Fatalf("failed to set driver: %s", err.Error())
 	}
 
-	n, p, err := parameterIndicators("postgres")
+	d, err := dialectFor("postgres")
 	if err != nil {
 		t.Fatalf("unexpected error: %s", err.Error())
 	}
 
 	const query = "SELECT * FROM tablename WHERE id = :id OR name = :name;"
 	// Act
-	parameterisedQuery, args, err := parameteriseQuery(n, p, query, map[string]any{"name": "123", "id": "123"})
+	parameterisedQuery, args, err := parameteriseQuery(d, query, map[string]any{"name": "123", "id": "123"})
 
 	// Assert
 	if err != nil {
@@ -88,3 +176,109 @@ func Test_Postgres_ParameteriseQuery_Substitution_Multiple_Parameters(t *testing
 		t.Fatalf("value '%s' does not equal expected '%s'", parameterisedQuery, expectedParameterisedQuery)
 	}
 }
+
+type pointerResult struct {
+	ID   string `db:"id"`
+	Name string `db:"name"`
+}
+
+func Test_Query_Pointer_To_Struct(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{"1", "foo"}, {"2", "bar"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	results, err := Query[*pointerResult](context.Background(), db, "SELECT id, name FROM foo;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(results))
+	}
+
+	if *results[0] != (pointerResult{ID: "1", Name: "foo"}) || *results[1] != (pointerResult{ID: "2", Name: "bar"}) {
+		t.Fatalf("unexpected results '%v' '%v'", *results[0], *results[1])
+	}
+}
+
+func Test_QueryFirst_Pointer_To_Struct(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{"1", "foo"}, {"2", "bar"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	result, err := QueryFirst[*pointerResult](context.Background(), db, "SELECT id, name FROM foo;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if result == nil || *result != (pointerResult{ID: "1", Name: "foo"}) {
+		t.Fatalf("unexpected result '%v'", result)
+	}
+}
+
+func Test_QueryFirstOrDefault_Pointer_To_Struct_Nil_Default(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{columns: []string{"id", "name"}})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	result, err := QueryFirstOrDefault[*pointerResult](context.Background(), db, nil, "SELECT id, name FROM foo;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if result != nil {
+		t.Fatalf("expected nil, found '%v'", result)
+	}
+}
+
+func Test_Query_Scanner_Is_Scanned_As_Single_Value(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"name"},
+		rows:    [][]driver.Value{{"foo"}, {nil}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	results, err := Query[*sql.NullString](context.Background(), db, "SELECT name FROM foo;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(results) != 2 || results[0] == nil || results[0].String != "foo" || results[1] != nil {
+		t.Fatalf("unexpected results '%v'", results)
+	}
+}
@@ -0,0 +1,94 @@
+package tqlmock
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+var errNotSupported = errors.New("tqlmock: not supported, use ExpectQuery or ExpectExec")
+
+type connector struct {
+	mock *Mock
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return conn(c), nil }
+func (c connector) Driver() driver.Driver                        { return mockDriver(c) }
+
+type mockDriver struct {
+	mock *Mock
+}
+
+func (mockDriver) Open(string) (driver.Conn, error) { return nil, errNotSupported }
+
+// Dialect
+// Reports the dialect of the Mock, so the dialect of its DB is detected.
+func (d mockDriver) Dialect() tql.Dialect { return d.mock.dialect }
+
+type conn struct {
+	mock *Mock
+}
+
+func (c conn) Prepare(string) (driver.Stmt, error) { return nil, errNotSupported }
+func (c conn) Close() error                        { return nil }
+func (c conn) Begin() (driver.Tx, error)           { return nil, errNotSupported }
+
+// CheckNamedValue
+// Accepts the arguments as they are, so they are matched against the expected arguments
+// without being converted by database/sql.
+func (c conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.mock.match(kindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	if e.rows == nil {
+		return &rows{rows: &Rows{}}, nil
+	}
+
+	return &rows{rows: e.rows}, nil
+}
+
+func (c conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.mock.match(kindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	if e.result == nil {
+		return result{}, nil
+	}
+
+	return e.result, nil
+}
+
+type rows struct {
+	rows *Rows
+	next int
+}
+
+func (r *rows) Columns() []string { return r.rows.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows.values) {
+		return io.EOF
+	}
+
+	copy(dest, r.rows.values[r.next])
+	r.next++
+	return nil
+}
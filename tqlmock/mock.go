@@ -0,0 +1,275 @@
+// Package tqlmock provides a fake database for unit tests of code built on tql. Tests declare the
+// queries they expect, in order, together with their arguments and the rows or results to return.
+//
+// The queries are matched after tql translated them, so the expected SQL uses the positional
+// parameters of the dialect the Mock was created with (e.g. $1 for tql.Postgres), and the expected
+// arguments are the ones tql bound to them:
+//
+//	m := tqlmock.New(t, tql.Postgres)
+//	m.ExpectQuery("SELECT id, name FROM foo WHERE id = $1;").
+//		WithArgs("1").
+//		WillReturnRows(tqlmock.Structs(Foo{ID: "1", Name: "foo"}))
+//
+//	foo, err := tql.QuerySingle[Foo](ctx, m, "SELECT id, name FROM foo WHERE id = :id;", map[string]any{"id": "1"})
+//
+// The test fails on queries which were not expected, and on expectations which were not met
+// once the test finishes.
+package tqlmock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+// TB
+// The subset of testing.TB used by the Mock.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Cleanup(f func())
+}
+
+type expectationKind string
+
+const (
+	kindQuery expectationKind = "query"
+	kindExec  expectationKind = "exec"
+)
+
+// anyArg matches every argument.
+type anyArg struct{}
+
+// AnyArg
+// Matches any value of the argument at its position, e.g. generated ids or timestamps.
+func AnyArg() any {
+	return anyArg{}
+}
+
+// Mock
+// Satisfies both tql.Querier and tql.Executor. Use DB for code which needs a *sql.DB.
+type Mock struct {
+	t       TB
+	db      *sql.DB
+	dialect tql.Dialect
+
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// New
+// Creates a Mock of a database of dialect d, which checks that all the expectations were met once
+// the test finishes.
+func New(t TB, d tql.Dialect) *Mock {
+	m := &Mock{t: t, dialect: d}
+	m.db = sql.OpenDB(connector{m})
+
+	t.Cleanup(func() {
+		t.Helper()
+
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s", err.Error())
+		}
+
+		_ = m.db.Close()
+	})
+
+	return m
+}
+
+// Dialect
+// Returns the dialect the Mock was created with, so tql translates the queries for it both when
+// using the Mock and the DB.
+func (m *Mock) Dialect() tql.Dialect {
+	return m.dialect
+}
+
+// DB
+// Returns a *sql.DB whose queries are matched against the expectations of the Mock.
+func (m *Mock) DB() *sql.DB {
+	return m.db
+}
+
+// ExpectQuery
+// Expects the query to be the next statement run through QueryContext or QueryRowContext.
+// Whitespace differences are ignored.
+func (m *Mock) ExpectQuery(query string) *Expectation {
+	return m.expect(kindQuery, query)
+}
+
+// ExpectExec
+// Expects the statement to be the next statement run through ExecContext.
+// Whitespace differences are ignored.
+func (m *Mock) ExpectExec(query string) *Expectation {
+	return m.expect(kindExec, query)
+}
+
+// ExpectationsWereMet
+// Returns an error listing the expectations which were not met yet.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var unmet []string
+	for _, e := range m.expectations {
+		if !e.met {
+			unmet = append(unmet, e.String())
+		}
+	}
+
+	if len(unmet) > 0 {
+		return fmt.Errorf("tqlmock: expectations were not met:\n\t%s", strings.Join(unmet, "\n\t"))
+	}
+
+	return nil
+}
+
+func (m *Mock) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return m.db.QueryContext(ctx, query, args...)
+}
+
+func (m *Mock) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
+
+func (m *Mock) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *Mock) expect(kind expectationKind, query string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &Expectation{mock: m, kind: kind, query: normalize(query)}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// match
+// Marks the next expectation as met if it matches the statement, or fails the test.
+func (m *Mock) match(kind expectationKind, query string, args []driver.NamedValue) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	call := fmt.Sprintf("%s %s %v", kind, normalize(query), values)
+
+	var next *Expectation
+	for _, e := range m.expectations {
+		if !e.met {
+			next = e
+			break
+		}
+	}
+
+	var err error
+	switch {
+	case next == nil:
+		err = fmt.Errorf("tqlmock: unexpected call, all expectations were already met:\n\t%s", call)
+	case next.kind != kind || next.query != normalize(query):
+		err = fmt.Errorf("tqlmock: unexpected call:\n\t%s\nexpected:\n\t%s", call, next.String())
+	case !argsMatch(next.args, values):
+		err = fmt.Errorf("tqlmock: unexpected arguments:\n\t%s\nexpected:\n\t%s", call, next.String())
+	}
+
+	if err != nil {
+		m.t.Errorf("%s", err.Error())
+		return nil, err
+	}
+
+	next.met = true
+	return next, nil
+}
+
+func argsMatch(expected []any, actual []any) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for i := range expected {
+		if _, ok := expected[i].(anyArg); ok {
+			continue
+		}
+
+		if !reflect.DeepEqual(expected[i], actual[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// Expectation
+// A statement the Mock expects, and what it returns once the statement is run.
+type Expectation struct {
+	mock  *Mock
+	kind  expectationKind
+	query string
+	args  []any
+
+	rows   *Rows
+	result driver.Result
+	err    error
+
+	met bool
+}
+
+// WithArgs
+// Sets the arguments the statement is expected with, after tql bound the named parameters.
+// Statements expected without WithArgs are expected without arguments.
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args = args
+	return e
+}
+
+// WillReturnRows
+// Sets the rows returned by the query.
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	if rows.err != nil {
+		e.mock.t.Helper()
+		e.mock.t.Errorf("tqlmock: invalid rows for %s: %s", e.String(), rows.err.Error())
+	}
+
+	e.rows = rows
+	return e
+}
+
+// WillReturnResult
+// Sets the result of the statement.
+func (e *Expectation) WillReturnResult(lastInsertID, rowsAffected int64) *Expectation {
+	e.result = result{lastInsertID: lastInsertID, rowsAffected: rowsAffected}
+	return e
+}
+
+// WillReturnError
+// Makes the statement fail with err.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) String() string {
+	return fmt.Sprintf("%s %s %v", e.kind, e.query, e.args)
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
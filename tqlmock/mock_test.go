@@ -0,0 +1,286 @@
+package tqlmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+// recorder
+// Records the failures reported by the Mock, so the tests can assert on them.
+type recorder struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *recorder) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+type foo struct {
+	ID       string  `db:"id"`
+	Value    int     `db:"value"`
+	Nullable *string `db:"nullable"`
+}
+
+func Test_Mock_Query_Structs(t *testing.T) {
+	// Arrange
+
+	nullable := "nullable"
+	expected := []foo{{ID: "1", Value: 1, Nullable: &nullable}, {ID: "2", Value: 2}}
+
+	m := New(t, tql.Postgres)
+	m.ExpectQuery("SELECT id, value, nullable FROM foo WHERE value > $1 AND id <> $2;").
+		WithArgs(0, "3").
+		WillReturnRows(Structs(expected...))
+
+	// Act
+	result, err := tql.Query[foo](
+		context.Background(),
+		m,
+		`SELECT id, value, nullable
+		FROM foo
+		WHERE value > :value AND id <> :id;`,
+		map[string]any{"value": 0, "id": "3"},
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if len(result) != len(expected) {
+		t.Fatalf("expected len %d found %d", len(expected), len(result))
+	}
+
+	if result[0].ID != "1" || result[0].Value != 1 || result[0].Nullable == nil || *result[0].Nullable != nullable {
+		t.Fatalf("value '%v' does not equal expected '%v'", result[0], expected[0])
+	}
+
+	if result[1].ID != "2" || result[1].Value != 2 || result[1].Nullable != nil {
+		t.Fatalf("value '%v' does not equal expected '%v'", result[1], expected[1])
+	}
+}
+
+func Test_Mock_Query_Maps_And_Table(t *testing.T) {
+	// Arrange
+
+	m := New(t, tql.Postgres)
+	m.ExpectQuery("SELECT id, value, nullable FROM foo;").
+		WillReturnRows(Maps(
+			map[string]any{"id": "1", "value": 1, "nullable": "nullable"},
+			map[string]any{"id": "2", "value": 2},
+		))
+	m.ExpectQuery("SELECT COUNT(*) FROM foo;").
+		WillReturnRows(Table([]string{"count"}, []any{2}))
+
+	// Act
+	result, queryErr := tql.Query[foo](context.Background(), m, "SELECT id, value, nullable FROM foo;")
+	count, countErr := tql.QuerySingle[int](context.Background(), m, "SELECT COUNT(*) FROM foo;")
+
+	// Assert
+	if queryErr != nil {
+		t.Fatalf("unexpected err: %s", queryErr.Error())
+	}
+
+	if len(result) != 2 || result[0].ID != "1" || result[0].Nullable == nil || result[1].ID != "2" || result[1].Nullable != nil {
+		t.Fatalf("unexpected result '%v'", result)
+	}
+
+	if countErr != nil {
+		t.Fatalf("unexpected err: %s", countErr.Error())
+	}
+
+	if count != 2 {
+		t.Fatalf("value '%d' does not equal expected '%d'", count, 2)
+	}
+}
+
+func Test_Mock_Exec(t *testing.T) {
+	// Arrange
+
+	expectedErr := errors.New("duplicate key")
+
+	m := New(t, tql.Postgres)
+	m.ExpectExec("INSERT INTO foo (id, value) VALUES ($1, $2);").
+		WithArgs("1", AnyArg()).
+		WillReturnResult(0, 1)
+	m.ExpectExec("INSERT INTO foo (id, value) VALUES ($1, $2);").
+		WithArgs("1", 2).
+		WillReturnError(expectedErr)
+
+	const stmt = "INSERT INTO foo (id, value) VALUES (:id, :value);"
+
+	// Act
+	result, firstErr := tql.Exec(context.Background(), m, stmt, foo{ID: "1", Value: 1})
+	_, secondErr := tql.Exec(context.Background(), m, stmt, foo{ID: "1", Value: 2})
+
+	// Assert
+	if firstErr != nil {
+		t.Fatalf("unexpected err: %s", firstErr.Error())
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if rowsAffected != 1 {
+		t.Fatalf("value '%d' does not equal expected '%d'", rowsAffected, 1)
+	}
+
+	if !errors.Is(secondErr, expectedErr) {
+		t.Fatalf("expected '%v' found '%v'", expectedErr, secondErr)
+	}
+}
+
+func Test_Mock_Fails_On_Unexpected_Calls(t *testing.T) {
+	tests := []struct {
+		name     string
+		expect   func(m *Mock)
+		expected string
+	}{
+		{
+			name: "different query",
+			expect: func(m *Mock) {
+				m.ExpectQuery("SELECT id FROM foo WHERE id = $1;").WithArgs("1")
+			},
+			expected: "tqlmock: unexpected call",
+		},
+		{
+			name: "different arguments",
+			expect: func(m *Mock) {
+				m.ExpectQuery("SELECT id FROM bar WHERE id = $1;").WithArgs("2")
+			},
+			expected: "tqlmock: unexpected arguments",
+		},
+		{
+			name: "exec instead of query",
+			expect: func(m *Mock) {
+				m.ExpectExec("SELECT id FROM bar WHERE id = $1;").WithArgs("1")
+			},
+			expected: "tqlmock: unexpected call",
+		},
+		{
+			name:     "no expectations",
+			expect:   func(*Mock) {},
+			expected: "tqlmock: unexpected call, all expectations were already met",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Arrange
+
+			r := &recorder{}
+			m := New(r, tql.Postgres)
+			test.expect(m)
+
+			// Act
+			_, err := tql.Query[string](
+				context.Background(),
+				m,
+				"SELECT id FROM bar WHERE id = :id;",
+				map[string]any{"id": "1"},
+			)
+
+			// Assert
+			if err == nil {
+				t.Fatalf("expected error, found nil")
+			}
+
+			if len(r.errors) == 0 || !strings.Contains(r.errors[0], test.expected) {
+				t.Fatalf("value '%v' does not contain expected '%s'", r.errors, test.expected)
+			}
+		})
+	}
+}
+
+func Test_Mock_Fails_On_Unmet_Expectations(t *testing.T) {
+	// Arrange
+	r := &recorder{}
+	m := New(r, tql.Postgres)
+	m.ExpectExec("DELETE FROM foo;")
+
+	// Act
+	r.finish()
+
+	// Assert
+	if len(r.errors) != 1 {
+		t.Fatalf("expected len %d found %d", 1, len(r.errors))
+	}
+
+	if !strings.Contains(r.errors[0], "exec DELETE FROM foo;") {
+		t.Fatalf("value '%s' does not contain expected '%s'", r.errors[0], "exec DELETE FROM foo;")
+	}
+}
+
+func Test_Rows_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     *Rows
+		expected string
+	}{
+		{
+			name:     "table row length",
+			rows:     Table([]string{"id", "value"}, []any{"1"}),
+			expected: "row 0 has 1 values, expected 2",
+		},
+		{
+			name:     "not a struct",
+			rows:     Structs(1, 2),
+			expected: "expected a struct, found int",
+		},
+		{
+			name:     "unsupported value",
+			rows:     Maps(map[string]any{"id": []string{"1"}}),
+			expected: "column id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Assert
+			if test.rows.err == nil {
+				t.Fatalf("expected error, found nil")
+			}
+
+			if !strings.Contains(test.rows.err.Error(), test.expected) {
+				t.Fatalf("value '%s' does not contain expected '%s'", test.rows.err.Error(), test.expected)
+			}
+		})
+	}
+}
+
+func Test_Mock_DB_Reports_Dialect(t *testing.T) {
+	// Arrange
+	m := New(t, tql.MySQL)
+
+	// Act
+	d, err := tql.DialectOf(m.DB())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if d != tql.MySQL {
+		t.Fatalf("expected dialect %v found %v", tql.MySQL, d)
+	}
+}
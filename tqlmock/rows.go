@@ -0,0 +1,130 @@
+package tqlmock
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Rows
+// The columns and values returned by an expected query.
+type Rows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+// Table
+// Creates rows from the column names and the values of every row, in the order of the columns.
+func Table(columns []string, rows ...[]any) *Rows {
+	r := Rows{columns: columns}
+
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			r.err = fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(columns))
+			return &r
+		}
+
+		if r.err = r.add(row); r.err != nil {
+			return &r
+		}
+	}
+
+	return &r
+}
+
+// Maps
+// Creates rows from maps of column names to values. The columns are the keys of all
+// the maps, ordered by name, and the values missing from a map are NULL.
+func Maps(rows ...map[string]any) *Rows {
+	columnSet := make(map[string]struct{})
+	for _, row := range rows {
+		for column := range row {
+			columnSet[column] = struct{}{}
+		}
+	}
+
+	r := Rows{columns: slices.Sorted(maps.Keys(columnSet))}
+
+	for _, row := range rows {
+		values := make([]any, len(r.columns))
+		for i, column := range r.columns {
+			values[i] = row[column]
+		}
+
+		if r.err = r.add(values); r.err != nil {
+			return &r
+		}
+	}
+
+	return &r
+}
+
+// Structs
+// Creates rows from structs, using the names from the `db` tags of their fields as columns,
+// the same way tql maps the columns to the fields. Unexported fields and fields without a `db` tag
+// are skipped.
+func Structs[T any](items ...T) *Rows {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return &Rows{err: fmt.Errorf("expected a struct, found %s", t)}
+	}
+
+	var (
+		columns []string
+		indices []int
+	)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag, found := field.Tag.Lookup("db")
+		if !found || !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if slices.Contains(strings.Split(opts, ","), "many") {
+			continue
+		}
+
+		columns = append(columns, name)
+		indices = append(indices, i)
+	}
+
+	r := Rows{columns: columns}
+
+	for _, item := range items {
+		value := reflect.ValueOf(item)
+
+		values := make([]any, len(indices))
+		for i, idx := range indices {
+			values[i] = value.Field(idx).Interface()
+		}
+
+		if r.err = r.add(values); r.err != nil {
+			return &r
+		}
+	}
+
+	return &r
+}
+
+func (r *Rows) add(row []any) error {
+	values := make([]driver.Value, len(row))
+
+	for i, v := range row {
+		value, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", r.columns[i], err)
+		}
+
+		values[i] = value
+	}
+
+	r.values = append(r.values, values)
+	return nil
+}
@@ -0,0 +1,193 @@
+package tqlreplay
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+type interactionKind string
+
+const (
+	kindQuery    interactionKind = "query"
+	kindExec     interactionKind = "exec"
+	kindBegin    interactionKind = "begin"
+	kindCommit   interactionKind = "commit"
+	kindRollback interactionKind = "rollback"
+)
+
+// cassette
+// The recorded interactions, in the order they happened, and the name of the dialect of the
+// recorded database. The name is empty if the dialect is not one of the built-in dialects.
+type cassette struct {
+	Dialect      string        `json:"dialect,omitempty"`
+	Interactions []interaction `json:"interactions"`
+}
+
+// dialectNames
+// The names of the built-in dialects stored in the recordings.
+var dialectNames = map[string]tql.Dialect{
+	"postgres":   tql.Postgres,
+	"mysql":      tql.MySQL,
+	"sqlite":     tql.SQLite,
+	"sqlserver":  tql.SQLServer,
+	"oracle":     tql.Oracle,
+	"duckdb":     tql.DuckDB,
+	"clickhouse": tql.ClickHouse,
+}
+
+// dialectName
+// Returns the name of the built-in dialect d, or an empty string if it isn't built-in.
+func dialectName(d tql.Dialect) string {
+	for name, builtIn := range dialectNames {
+		// Dialects of different types are never equal, so custom dialects which are not
+		// comparable don't panic.
+		if d == builtIn {
+			return name
+		}
+	}
+	return ""
+}
+
+type interaction struct {
+	Kind  interactionKind `json:"kind"`
+	Query string          `json:"query,omitempty"`
+	Args  []value         `json:"args,omitempty"`
+
+	ResultSets []resultSet `json:"result_sets,omitempty"`
+
+	LastInsertID      int64  `json:"last_insert_id,omitempty"`
+	LastInsertIDError string `json:"last_insert_id_error,omitempty"`
+	RowsAffected      int64  `json:"rows_affected,omitempty"`
+	RowsAffectedError string `json:"rows_affected_error,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type resultSet struct {
+	Columns []column  `json:"columns"`
+	Rows    [][]value `json:"rows"`
+}
+
+type column struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"database_type,omitempty"`
+}
+
+// value
+// A driver.Value, encoded together with its type so it is decoded to the same type.
+type value struct {
+	v driver.Value
+}
+
+type encodedValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+func (v value) MarshalJSON() ([]byte, error) {
+	var e encodedValue
+
+	switch x := v.v.(type) {
+	case nil:
+		e = encodedValue{Type: "null"}
+	case int64:
+		e = encodedValue{Type: "int64", Value: strconv.FormatInt(x, 10)}
+	case float64:
+		e = encodedValue{Type: "float64", Value: strconv.FormatFloat(x, 'g', -1, 64)}
+	case bool:
+		e = encodedValue{Type: "bool", Value: strconv.FormatBool(x)}
+	case string:
+		e = encodedValue{Type: "string", Value: x}
+	case []byte:
+		e = encodedValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(x)}
+	case time.Time:
+		e = encodedValue{Type: "time", Value: x.Format(time.RFC3339Nano)}
+	default:
+		return nil, fmt.Errorf("tqlreplay: unsupported value type %T", v.v)
+	}
+
+	return json.Marshal(e)
+}
+
+func (v *value) UnmarshalJSON(data []byte) error {
+	var e encodedValue
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+
+	var err error
+	switch e.Type {
+	case "null":
+		v.v = nil
+	case "int64":
+		v.v, err = strconv.ParseInt(e.Value, 10, 64)
+	case "float64":
+		v.v, err = strconv.ParseFloat(e.Value, 64)
+	case "bool":
+		v.v, err = strconv.ParseBool(e.Value)
+	case "string":
+		v.v = e.Value
+	case "bytes":
+		v.v, err = base64.StdEncoding.DecodeString(e.Value)
+	case "time":
+		v.v, err = time.Parse(time.RFC3339Nano, e.Value)
+	default:
+		err = fmt.Errorf("tqlreplay: unsupported value type %s", e.Type)
+	}
+
+	return err
+}
+
+func encodeArgs(args []driver.NamedValue) []value {
+	values := make([]value, len(args))
+	for i, arg := range args {
+		values[i] = value{arg.Value}
+	}
+	return values
+}
+
+// argsEqual
+// Compares the arguments by their encoding, so time.Time values with a different
+// location, but the same instant and offset, are equal.
+func argsEqual(a, b []value) bool {
+	return slices.EqualFunc(a, b, func(x, y value) bool {
+		xj, xErr := x.MarshalJSON()
+		yj, yErr := y.MarshalJSON()
+		return xErr == nil && yErr == nil && string(xj) == string(yj)
+	})
+}
+
+func readCassette(path string) (cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cassette{}, err
+	}
+
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return cassette{}, fmt.Errorf("tqlreplay: invalid recording %s: %w", path, err)
+	}
+
+	if _, found := dialectNames[c.Dialect]; c.Dialect != "" && !found {
+		return cassette{}, fmt.Errorf("tqlreplay: invalid recording %s: unsupported dialect %s", path, c.Dialect)
+	}
+
+	return c, nil
+}
+
+func writeCassette(path string, c cassette) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,338 @@
+package tqlreplay
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+// Recorder
+// A driver.Connector whose connections proxy the connections of another connector, and record
+// every interaction with them.
+//
+// The recorded interactions are written to the file by Save, which is called by sql.DB.Close.
+type Recorder struct {
+	connector driver.Connector
+	path      string
+
+	mu       sync.Mutex
+	cassette cassette
+}
+
+// NewRecorder
+// Records the interactions with the connections opened by connector to the file at path.
+func NewRecorder(connector driver.Connector, path string) *Recorder {
+	return &Recorder{connector: connector, path: path}
+}
+
+// NewRecorderFromDriver
+// Records the interactions with the connections of the registered driver to the file at path.
+func NewRecorderFromDriver(driverName, dataSourceName, path string) (*Recorder, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	d := db.Driver()
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dataSourceName)
+		if err != nil {
+			return nil, err
+		}
+		return NewRecorder(connector, path), nil
+	}
+
+	return NewRecorder(dsnConnector{dsn: dataSourceName, driver: d}, path), nil
+}
+
+func (r *Recorder) Connect(ctx context.Context) (driver.Conn, error) {
+	c, err := r.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordConn{recorder: r, conn: c}, nil
+}
+
+func (r *Recorder) Driver() driver.Driver { return r.connector.Driver() }
+
+// Save
+// Writes the interactions recorded so far to the file, together with the dialect of the driver
+// if it is detected.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d, err := tql.DialectOf(r.connector); err == nil {
+		r.cassette.Dialect = dialectName(d)
+	}
+
+	return writeCassette(r.path, r.cassette)
+}
+
+// Close
+// Saves the recorded interactions. It is called by sql.DB.Close.
+func (r *Recorder) Close() error {
+	err := r.Save()
+
+	if closer, ok := r.connector.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
+	}
+
+	return err
+}
+
+func (r *Recorder) record(i interaction, err error) {
+	if err != nil {
+		i.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+}
+
+type recordConn struct {
+	recorder *Recorder
+	conn     driver.Conn
+}
+
+func (c *recordConn) Prepare(string) (driver.Stmt, error) { return nil, errPrepareNotSupported }
+func (c *recordConn) Close() error                        { return c.conn.Close() }
+
+func (c *recordConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recordConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var (
+		tx  driver.Tx
+		err error
+	)
+
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		//nolint:staticcheck // Fallback for drivers which do not implement driver.ConnBeginTx.
+		tx, err = c.conn.Begin()
+	}
+
+	c.recorder.record(interaction{Kind: kindBegin}, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordTx{recorder: c.recorder, tx: tx}, nil
+}
+
+func (c *recordConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	i := interaction{Kind: kindQuery, Query: query, Args: encodeArgs(args)}
+
+	resultSets, err := c.query(ctx, query, args)
+	i.ResultSets = resultSets
+
+	c.recorder.record(i, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{resultSets: resultSets}, nil
+}
+
+func (c *recordConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	i := interaction{Kind: kindExec, Query: query, Args: encodeArgs(args)}
+
+	res, err := c.exec(ctx, query, args)
+	if err == nil {
+		i.LastInsertID, err = res.LastInsertId()
+		if err != nil {
+			i.LastInsertIDError, err = err.Error(), nil
+		}
+
+		i.RowsAffected, err = res.RowsAffected()
+		if err != nil {
+			i.RowsAffectedError, err = err.Error(), nil
+		}
+	}
+
+	c.recorder.record(i, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return result{i}, nil
+}
+
+func (c *recordConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *recordConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// query
+// Runs the query on the proxied connection, and reads all of its result sets.
+func (c *recordConn) query(ctx context.Context, query string, args []driver.NamedValue) ([]resultSet, error) {
+	if queryer, ok := c.conn.(driver.QueryerContext); ok {
+		r, err := queryer.QueryContext(ctx, query, args)
+		if !errors.Is(err, driver.ErrSkip) {
+			if err != nil {
+				return nil, err
+			}
+			return readResultSets(r)
+		}
+	}
+
+	stmt, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	var r driver.Rows
+	if queryer, ok := stmt.(driver.StmtQueryContext); ok {
+		r, err = queryer.QueryContext(ctx, args)
+	} else {
+		//nolint:staticcheck // Fallback for drivers which do not implement driver.StmtQueryContext.
+		r, err = stmt.Query(namedValuesToValues(args))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return readResultSets(r)
+}
+
+// exec
+// Runs the statement on the proxied connection.
+func (c *recordConn) exec(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.conn.(driver.ExecerContext); ok {
+		res, err := execer.ExecContext(ctx, query, args)
+		if !errors.Is(err, driver.ErrSkip) {
+			return res, err
+		}
+	}
+
+	stmt, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+
+	//nolint:staticcheck // Fallback for drivers which do not implement driver.StmtExecContext.
+	return stmt.Exec(namedValuesToValues(args))
+}
+
+func (c *recordConn) prepare(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+type recordTx struct {
+	recorder *Recorder
+	tx       driver.Tx
+}
+
+func (tx *recordTx) Commit() error {
+	err := tx.tx.Commit()
+	tx.recorder.record(interaction{Kind: kindCommit}, err)
+	return err
+}
+
+func (tx *recordTx) Rollback() error {
+	err := tx.tx.Rollback()
+	tx.recorder.record(interaction{Kind: kindRollback}, err)
+	return err
+}
+
+// readResultSets
+// Reads and closes the rows, copying the values as drivers may reuse their buffers between rows.
+func readResultSets(r driver.Rows) ([]resultSet, error) {
+	defer func() { _ = r.Close() }()
+
+	var resultSets []resultSet
+
+	for {
+		names := r.Columns()
+
+		set := resultSet{Columns: make([]column, len(names)), Rows: [][]value{}}
+		for i, name := range names {
+			set.Columns[i].Name = name
+			if typed, ok := r.(driver.RowsColumnTypeDatabaseTypeName); ok {
+				set.Columns[i].DatabaseType = typed.ColumnTypeDatabaseTypeName(i)
+			}
+		}
+
+		dest := make([]driver.Value, len(names))
+		for {
+			err := r.Next(dest)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			row := make([]value, len(dest))
+			for i, v := range dest {
+				switch x := v.(type) {
+				case nil, int64, float64, bool, string, time.Time:
+					row[i] = value{x}
+				case []byte:
+					row[i] = value{append([]byte(nil), x...)}
+				default:
+					return nil, fmt.Errorf("tqlreplay: unsupported value type %T in column %s", v, names[i])
+				}
+			}
+
+			set.Rows = append(set.Rows, row)
+		}
+
+		resultSets = append(resultSets, set)
+
+		next, ok := r.(driver.RowsNextResultSet)
+		if !ok || !next.HasNextResultSet() {
+			return resultSets, nil
+		}
+
+		if err := next.NextResultSet(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return resultSets, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+func namedValuesToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
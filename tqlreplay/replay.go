@@ -0,0 +1,236 @@
+package tqlreplay
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+var errPrepareNotSupported = errors.New("tqlreplay: prepared statements are not supported")
+
+// Replayer
+// A driver.Connector whose connections serve the recorded interactions, in order,
+// instead of connecting to a database.
+type Replayer struct {
+	path    string
+	dialect tql.Dialect
+
+	mu           sync.Mutex
+	interactions []interaction
+	next         int
+}
+
+// NewReplayer
+// Reads the interactions recorded to the file at path.
+func NewReplayer(path string) (*Replayer, error) {
+	c, err := readCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{path: path, dialect: dialectNames[c.Dialect], interactions: c.Interactions}, nil
+}
+
+func (r *Replayer) Connect(context.Context) (driver.Conn, error) { return replayConn{r}, nil }
+func (r *Replayer) Driver() driver.Driver                        { return replayDriver{r} }
+
+// Dialect
+// Returns the dialect of the recorded database, so the queries are translated for it without
+// a driver of the database. It is nil if the dialect of the recording is unknown.
+func (r *Replayer) Dialect() tql.Dialect {
+	return r.dialect
+}
+
+// Verify
+// Returns an error if some of the recorded interactions were not replayed.
+func (r *Replayer) Verify() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if remaining := len(r.interactions) - r.next; remaining > 0 {
+		next := r.interactions[r.next]
+		return fmt.Errorf(
+			"tqlreplay: %d recorded interactions of %s were not replayed, starting with %s %q",
+			remaining,
+			r.path,
+			next.Kind,
+			next.Query,
+		)
+	}
+
+	return nil
+}
+
+// take
+// Returns the next recorded interaction, which needs to match the kind, the query and the arguments.
+func (r *Replayer) take(kind interactionKind, query string, args []value) (interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next >= len(r.interactions) {
+		return interaction{}, fmt.Errorf("tqlreplay: unexpected %s %q, all the recorded interactions of %s were replayed", kind, query, r.path)
+	}
+
+	next := r.interactions[r.next]
+	if next.Kind != kind || next.Query != query || !argsEqual(next.Args, args) {
+		return interaction{}, fmt.Errorf(
+			"tqlreplay: unexpected %s %q with %d arguments, recorded interaction %d of %s is %s %q with %d arguments",
+			kind,
+			query,
+			len(args),
+			r.next,
+			r.path,
+			next.Kind,
+			next.Query,
+			len(next.Args),
+		)
+	}
+
+	r.next++
+
+	if next.Error != "" {
+		return next, errors.New(next.Error)
+	}
+
+	return next, nil
+}
+
+type replayDriver struct {
+	replayer *Replayer
+}
+
+func (replayDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("tqlreplay: use sql.OpenDB with a Replayer")
+}
+
+// Dialect
+// Reports the dialect of the Replayer, so the dialect of the database opened with it is detected.
+func (d replayDriver) Dialect() tql.Dialect { return d.replayer.dialect }
+
+type replayConn struct {
+	replayer *Replayer
+}
+
+func (c replayConn) Prepare(string) (driver.Stmt, error) { return nil, errPrepareNotSupported }
+func (c replayConn) Close() error                        { return nil }
+
+func (c replayConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c replayConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.replayer.take(kindBegin, "", nil); err != nil {
+		return nil, err
+	}
+	return replayTx(c), nil
+}
+
+func (c replayConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	i, err := c.replayer.take(kindQuery, query, encodeArgs(args))
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{resultSets: i.ResultSets}, nil
+}
+
+func (c replayConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	i, err := c.replayer.take(kindExec, query, encodeArgs(args))
+	if err != nil {
+		return nil, err
+	}
+
+	return result{i}, nil
+}
+
+type replayTx struct {
+	replayer *Replayer
+}
+
+func (tx replayTx) Commit() error {
+	_, err := tx.replayer.take(kindCommit, "", nil)
+	return err
+}
+
+func (tx replayTx) Rollback() error {
+	_, err := tx.replayer.take(kindRollback, "", nil)
+	return err
+}
+
+// result
+// The recorded result of a statement.
+type result struct {
+	interaction interaction
+}
+
+func (r result) LastInsertId() (int64, error) {
+	if r.interaction.LastInsertIDError != "" {
+		return 0, errors.New(r.interaction.LastInsertIDError)
+	}
+	return r.interaction.LastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	if r.interaction.RowsAffectedError != "" {
+		return 0, errors.New(r.interaction.RowsAffectedError)
+	}
+	return r.interaction.RowsAffected, nil
+}
+
+// rows
+// Serves the recorded result sets of a query.
+type rows struct {
+	resultSets []resultSet
+	set        int
+	row        int
+}
+
+func (r *rows) Columns() []string {
+	if len(r.resultSets) == 0 {
+		return nil
+	}
+
+	columns := r.resultSets[r.set].Columns
+
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.resultSets[r.set].Columns[index].DatabaseType
+}
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.resultSets) == 0 || r.row >= len(r.resultSets[r.set].Rows) {
+		return io.EOF
+	}
+
+	for i, v := range r.resultSets[r.set].Rows[r.row] {
+		dest[i] = v.v
+	}
+
+	r.row++
+	return nil
+}
+
+func (r *rows) HasNextResultSet() bool { return r.set < len(r.resultSets)-1 }
+
+func (r *rows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+
+	r.set++
+	r.row = 0
+	return nil
+}
@@ -0,0 +1,78 @@
+// Package tqlreplay records the queries a test suite runs against a real database to a file, and
+// replays them later without any database, e.g. in sandboxed environments without docker.
+//
+// In record mode, the connections proxy a real driver and store every statement, its arguments,
+// the column metadata and the rows it returned. In replay mode, the statements need to arrive in the
+// same order with the same arguments, and are served from the file. The file also stores the dialect
+// of the recorded database, which the replayed database reports to tql:
+//
+//	db, err := tqlreplay.Open(tqlreplay.ModeFromEnv(), "pgx", dsn, "testdata/postgres.json")
+//
+// The arguments need to be deterministic for the replay to match, e.g. fixed ids and timestamps instead
+// of generated ones. The arguments are converted by database/sql's default conversion rules, rather than
+// by the proxied driver. Prepared statements are not supported.
+package tqlreplay
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+)
+
+// EnvVarNameMode selects the Mode returned by ModeFromEnv.
+const EnvVarNameMode = "TQLREPLAY_MODE"
+
+type Mode string
+
+const (
+	// ModeReplay serves the statements from the file, without a database.
+	ModeReplay Mode = "replay"
+	// ModeRecord runs the statements against the database, and stores them to the file.
+	ModeRecord Mode = "record"
+)
+
+// ModeFromEnv
+// Returns ModeRecord if the TQLREPLAY_MODE environment variable is set to "record",
+// and ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	if Mode(os.Getenv(EnvVarNameMode)) == ModeRecord {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Open
+// Opens a database which either records to or replays from the file at path, depending on the mode.
+// The driver name and the data source name are only used to connect to the database in record mode.
+//
+// In record mode, the file is written once the database is closed.
+func Open(mode Mode, driverName, dataSourceName, path string) (*sql.DB, error) {
+	switch mode {
+	case ModeRecord:
+		r, err := NewRecorderFromDriver(driverName, dataSourceName, path)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(r), nil
+	case ModeReplay:
+		r, err := NewReplayer(path)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported tqlreplay mode: %s", mode)
+	}
+}
+
+// dsnConnector
+// Connects using a driver which does not implement driver.DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
@@ -0,0 +1,205 @@
+package tqlreplay
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+type dummyDriver struct{}
+
+func (d dummyDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// fakeConnector
+// Opens connections which return the same row for every query, so the tests can
+// record without a database.
+type fakeConnector struct {
+	columns []string
+	row     []driver.Value
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return dummyDriver{} }
+
+type fakeConn fakeConnector
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, fmt.Errorf("not implemented") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{columns: c.columns, row: c.row}, nil
+}
+
+func (c fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	row     []driver.Value
+	done    bool
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	copy(dest, r.row)
+	r.done = true
+	return nil
+}
+
+type foo struct {
+	ID        string    `db:"id"`
+	Value     int       `db:"value"`
+	Nullable  *string   `db:"nullable"`
+	Data      []byte    `db:"data"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func TestMain(m *testing.M) {
+	sql.Register("postgres", dummyDriver{})
+	m.Run()
+}
+
+func Test_Record_And_Replay(t *testing.T) {
+	// Arrange
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	connector := fakeConnector{
+		columns: []string{"id", "value", "nullable", "data", "created_at"},
+		row:     []driver.Value{"1", int64(42), nil, []byte("data"), createdAt},
+	}
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	const query = "SELECT id, value, nullable, data, created_at FROM foo WHERE id = :id;"
+	params := map[string]any{"id": "1"}
+
+	run := func(db *sql.DB) ([]foo, int64) {
+		t.Helper()
+
+		ctx := context.Background()
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err.Error())
+		}
+
+		result, err := tql.Exec(ctx, tx, "UPDATE foo SET value = :value WHERE id = :id;", map[string]any{"id": "1", "value": 42})
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err.Error())
+		}
+
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("unexpected err: %s", err.Error())
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err.Error())
+		}
+
+		foos, err := tql.Query[foo](ctx, db, query, params)
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err.Error())
+		}
+
+		return foos, rowsAffected
+	}
+
+	recordDB := sql.OpenDB(NewRecorder(connector, path))
+	recorded, recordedRowsAffected := run(recordDB)
+	if err := recordDB.Close(); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	// Act
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	replayDB := sql.OpenDB(replayer)
+	defer func() { _ = replayDB.Close() }()
+
+	replayed, replayedRowsAffected := run(replayDB)
+
+	// Assert
+	if err := replayer.Verify(); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if replayer.Dialect() != tql.Postgres {
+		t.Fatalf("expected dialect %v found %v", tql.Postgres, replayer.Dialect())
+	}
+
+	if replayedRowsAffected != recordedRowsAffected || replayedRowsAffected != 1 {
+		t.Fatalf("value '%d' does not equal expected '%d'", replayedRowsAffected, recordedRowsAffected)
+	}
+
+	if len(replayed) != 1 || len(recorded) != 1 {
+		t.Fatalf("expected len %d found %d", 1, len(replayed))
+	}
+
+	r := replayed[0]
+	if r.ID != "1" || r.Value != 42 || r.Nullable != nil || string(r.Data) != "data" || !r.CreatedAt.Equal(createdAt) {
+		t.Fatalf("value '%v' does not equal expected '%v'", r, recorded[0])
+	}
+}
+
+func Test_Replay_Fails_On_Unexpected_Query(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	recordDB := sql.OpenDB(NewRecorder(fakeConnector{columns: []string{"id"}, row: []driver.Value{"1"}}, path))
+	if _, err := tql.Query[string](context.Background(), recordDB, "SELECT id FROM foo WHERE id = $1;", "1"); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+	if err := recordDB.Close(); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	replayDB := sql.OpenDB(replayer)
+	defer func() { _ = replayDB.Close() }()
+
+	// Act
+	_, err = tql.Query[string](context.Background(), replayDB, "SELECT id FROM foo WHERE id = $1;", "2")
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+
+	if !strings.Contains(err.Error(), "tqlreplay: unexpected query") {
+		t.Fatalf("value '%s' does not contain expected '%s'", err.Error(), "tqlreplay: unexpected query")
+	}
+
+	if err := replayer.Verify(); err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
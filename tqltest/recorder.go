@@ -0,0 +1,286 @@
+package tqltest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+// updateEnv
+// Updates the golden files of all the Recorders when set to true, e.g. TQLTEST_UPDATE=1 go test ./...
+const updateEnv = "TQLTEST_UPDATE"
+
+var (
+	uuidRegex      = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	timestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`)
+)
+
+const (
+	uuidMask      = "<uuid>"
+	timestampMask = "<timestamp>"
+)
+
+// DB
+// The database the Recorder wraps, e.g. *sql.DB, *sql.Tx, *sql.Conn or *Savepoint.
+type DB interface {
+	tql.Querier
+	tql.Executor
+}
+
+// RecordedQuery
+// A single statement the Recorder received, after tql translated it.
+type RecordedQuery struct {
+	Kind  string
+	Query string
+	Args  []any
+}
+
+// MaskFunc
+// Replaces a volatile argument with a stable placeholder, returning false to leave the argument as it is.
+type MaskFunc func(arg any) (string, bool)
+
+type RecorderOpt func(*Recorder)
+
+// WithMask
+// Adds a mask applied to the arguments, before the built-in masks for UUIDs and timestamps.
+func WithMask(mask MaskFunc) RecorderOpt {
+	return func(r *Recorder) {
+		r.masks = append(r.masks, mask)
+	}
+}
+
+// WithUpdate
+// Sets whether AssertGolden writes the recorded statements to the golden file instead of comparing them,
+// overriding the -update flag and the TQLTEST_UPDATE environment variable.
+func WithUpdate(update bool) RecorderOpt {
+	return func(r *Recorder) {
+		r.update = &update
+	}
+}
+
+// Recorder
+// Records every statement run through it, in order, and compares them against a golden file.
+// It satisfies both tql.Querier and tql.Executor.
+type Recorder struct {
+	db     DB
+	masks  []MaskFunc
+	update *bool
+
+	mu      sync.Mutex
+	queries []RecordedQuery
+}
+
+// NewRecorder
+// Wraps db, recording the statements before they are passed on to it.
+func NewRecorder(db DB, opts ...RecorderOpt) *Recorder {
+	r := Recorder{db: db}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	r.masks = append(r.masks, maskUUID, maskTimestamp)
+	return &r
+}
+
+func (r *Recorder) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	r.record("query", query, args)
+	return r.db.QueryContext(ctx, query, args...)
+}
+
+func (r *Recorder) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	r.record("query", query, args)
+	return r.db.QueryRowContext(ctx, query, args...)
+}
+
+func (r *Recorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.record("exec", query, args)
+	return r.db.ExecContext(ctx, query, args...)
+}
+
+// Dialect
+// Returns the dialect of the wrapped database, so the statements are translated as they would be without
+// the Recorder. Returns nil if tql can't tell the dialect of the wrapped database.
+func (r *Recorder) Dialect() tql.Dialect {
+	return dialectOf(r.db)
+}
+
+// Queries
+// Returns the recorded statements, in the order they were run.
+func (r *Recorder) Queries() []RecordedQuery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]RecordedQuery(nil), r.queries...)
+}
+
+// AssertGolden
+// Fails the test if the recorded statements differ from the golden file. Running the tests with
+// TQLTEST_UPDATE=1, or with the -update flag if the test package defines one, writes the recorded
+// statements to the golden file instead.
+//
+// The whitespace of the statements is normalised, and the UUIDs and timestamps
+// in the arguments are masked.
+func (r *Recorder) AssertGolden(t testing.TB, path string) {
+	t.Helper()
+
+	recorded := r.render()
+
+	if r.shouldUpdate() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("tqltest: failed to create golden file directory: %s", err.Error())
+		}
+
+		if err := os.WriteFile(path, recorded, 0o644); err != nil {
+			t.Fatalf("tqltest: failed to update golden file: %s", err.Error())
+		}
+
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("tqltest: golden file %s not found, run the tests with %s=1 to create it", path, updateEnv)
+	}
+	if err != nil {
+		t.Fatalf("tqltest: failed to read golden file: %s", err.Error())
+	}
+
+	if !bytes.Equal(golden, recorded) {
+		t.Errorf(
+			"tqltest: recorded queries do not match golden file %s, run the tests with %s=1 if the change is intended\n"+
+				"--- golden\n%s\n--- recorded\n%s",
+			path,
+			updateEnv,
+			golden,
+			recorded,
+		)
+	}
+}
+
+// shouldUpdate
+// Reports whether the golden file should be updated. The -update flag is looked up when the
+// golden file is asserted, as it is defined by the test package, if at all.
+func (r *Recorder) shouldUpdate() bool {
+	if r.update != nil {
+		return *r.update
+	}
+
+	if f := flag.Lookup("update"); f != nil {
+		if getter, ok := f.Value.(flag.Getter); ok {
+			if update, ok := getter.Get().(bool); ok && update {
+				return true
+			}
+		}
+	}
+
+	update, _ := strconv.ParseBool(os.Getenv(updateEnv))
+	return update
+}
+
+func (r *Recorder) record(kind, query string, args []any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries = append(r.queries, RecordedQuery{
+		Kind:  kind,
+		Query: strings.Join(strings.Fields(query), " "),
+		Args:  args,
+	})
+}
+
+func (r *Recorder) render() []byte {
+	var b bytes.Buffer
+
+	for i, q := range r.Queries() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		args := make([]string, len(q.Args))
+		for j, arg := range q.Args {
+			args[j] = r.renderArg(arg)
+		}
+
+		fmt.Fprintf(&b, "%s: %s\nargs: [%s]\n", q.Kind, q.Query, strings.Join(args, ", "))
+	}
+
+	return b.Bytes()
+}
+
+func (r *Recorder) renderArg(arg any) string {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			arg = value
+		}
+	}
+
+	if v := reflect.ValueOf(arg); v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "nil"
+		}
+		arg = v.Elem().Interface()
+	}
+
+	for _, mask := range r.masks {
+		if masked, ok := mask(arg); ok {
+			return masked
+		}
+	}
+
+	if arg == nil {
+		return "nil"
+	}
+
+	if b, ok := arg.([]byte); ok {
+		return fmt.Sprintf("%q", b)
+	}
+
+	return fmt.Sprintf("%#v", arg)
+}
+
+func maskUUID(arg any) (string, bool) {
+	var s string
+	switch v := arg.(type) {
+	case string:
+		s = v
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return "", false
+	}
+
+	if uuidRegex.MatchString(s) {
+		return uuidMask, true
+	}
+
+	return "", false
+}
+
+func maskTimestamp(arg any) (string, bool) {
+	switch v := arg.(type) {
+	case time.Time:
+		return timestampMask, true
+	case string:
+		if timestampRegex.MatchString(v) {
+			return timestampMask, true
+		}
+	}
+
+	return "", false
+}
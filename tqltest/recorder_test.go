@@ -0,0 +1,148 @@
+package tqltest
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuel-skrenkovic/tql"
+	"github.com/emanuel-skrenkovic/tql/tqlmock"
+)
+
+// The -update flag of the golden files, defined by the test package as usual. Importing tqltest
+// must not define it as well.
+var _ = flag.Bool("update", false, "update the golden files")
+
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func record(t *testing.T, id string, opts ...RecorderOpt) *Recorder {
+	t.Helper()
+
+	m := tqlmock.New(t, tql.Postgres)
+	m.ExpectQuery("SELECT id, value FROM foo WHERE id = $1 AND created_at > $2;").
+		WithArgs(id, tqlmock.AnyArg()).
+		WillReturnRows(tqlmock.Table([]string{"id", "value"}, []any{id, 1}))
+	m.ExpectExec("UPDATE foo SET value = $1 WHERE id = $2;").
+		WithArgs(2, id).
+		WillReturnResult(0, 1)
+
+	r := NewRecorder(m, opts...)
+
+	ctx := context.Background()
+
+	_, err := tql.Query[struct {
+		ID    string `db:"id"`
+		Value int    `db:"value"`
+	}](
+		ctx,
+		r,
+		`SELECT id, value
+		FROM foo
+		WHERE id = :id AND created_at > :created_at;`,
+		map[string]any{"id": id, "created_at": time.Now()},
+	)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if _, err := tql.Exec(ctx, r, "UPDATE foo SET value = :value WHERE id = :id;", map[string]any{"id": id, "value": 2}); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	return r
+}
+
+func Test_Recorder_Matches_Golden_File(t *testing.T) {
+	// Arrange
+	r := record(t, "9b2f6a4e-1c43-4f0e-8d7e-3b5f1a2c4d6e")
+
+	// Act
+	queries := r.Queries()
+
+	// Assert
+	if len(queries) != 2 {
+		t.Fatalf("expected len %d found %d", 2, len(queries))
+	}
+
+	const expectedQuery = "SELECT id, value FROM foo WHERE id = $1 AND created_at > $2;"
+	if queries[0].Query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", queries[0].Query, expectedQuery)
+	}
+
+	r.AssertGolden(t, filepath.Join("testdata", "recorder.golden"))
+}
+
+func Test_Recorder_Reports_Golden_File_Mismatch(t *testing.T) {
+	// Arrange
+	r := record(t, "1")
+
+	path := filepath.Join(t.TempDir(), "recorder.golden")
+	if err := os.WriteFile(path, []byte("query: SELECT 1;\nargs: []\n"), 0o644); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	tb := &fakeTB{TB: t}
+
+	// Act
+	r.AssertGolden(tb, path)
+
+	// Assert
+	if len(tb.errors) != 1 {
+		t.Fatalf("expected len %d found %d", 1, len(tb.errors))
+	}
+
+	if !strings.Contains(tb.errors[0], "args: [\"1\", <timestamp>]") {
+		t.Fatalf("value '%s' does not contain the recorded queries", tb.errors[0])
+	}
+}
+
+func Test_Recorder_Updates_Golden_File(t *testing.T) {
+	// Arrange
+	r := record(t, "1", WithUpdate(true))
+
+	path := filepath.Join(t.TempDir(), "recorder.golden")
+
+	// Act
+	r.AssertGolden(t, path)
+
+	// Assert
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if !strings.Contains(string(golden), "exec: UPDATE foo SET value = $1 WHERE id = $2;") {
+		t.Fatalf("value '%s' does not contain the recorded queries", golden)
+	}
+}
+
+func Test_Recorder_Updates_Golden_File_From_Environment(t *testing.T) {
+	// Arrange
+	t.Setenv(updateEnv, "1")
+
+	r := record(t, "1")
+
+	path := filepath.Join(t.TempDir(), "recorder.golden")
+
+	// Act
+	r.AssertGolden(t, path)
+
+	// Assert
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+}
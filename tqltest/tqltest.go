@@ -0,0 +1,181 @@
+// Package tqltest isolates tests using the same database from each other by running every test
+// in a transaction, or a savepoint, which is rolled back once the test finishes.
+//
+// Tx begins a transaction per test, which suits server databases (Postgres, CockroachDB, MariaDB/MySQL).
+//
+// Shared runs all the tests on a single connection, and every test in a savepoint of its own. It suits
+// SQLite in-memory databases, where every connection opens a database of its own, and databases which
+// are set up once (e.g. in TestMain) and should be left unchanged by the tests.
+package tqltest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/emanuel-skrenkovic/tql"
+)
+
+// Tx
+// Begins a transaction which is rolled back once the test and all its subtests finish.
+// The transaction satisfies both tql.Querier and tql.Executor, and tql translates the queries
+// run through it for the dialect of db.
+//
+// The test fails if the transaction was committed, as its changes can no longer be rolled back.
+func Tx(t testing.TB, db *sql.DB) *Transaction {
+	t.Helper()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("tqltest: failed to begin transaction: %s", err.Error())
+	}
+
+	t.Cleanup(func() {
+		if err := tx.Rollback(); err != nil {
+			if errors.Is(err, sql.ErrTxDone) {
+				t.Errorf("tqltest: transaction was committed or rolled back by the test, its changes might have leaked")
+				return
+			}
+			t.Errorf("tqltest: failed to roll back transaction: %s", err.Error())
+		}
+	})
+
+	return &Transaction{Tx: tx, db: db}
+}
+
+// Transaction
+// The transaction of a single test, begun by Tx. The embedded *sql.Tx can be passed to the code
+// which needs one.
+type Transaction struct {
+	*sql.Tx
+	db *sql.DB
+}
+
+// Dialect
+// Returns the dialect of the database the transaction was begun on, or nil if tql can't tell it.
+func (tx *Transaction) Dialect() tql.Dialect {
+	return dialectOf(tx.db)
+}
+
+// Shared
+// A transaction on a single connection shared by the tests. Every test runs in a savepoint which
+// is rolled back once the test finishes, and the transaction is rolled back by Close.
+//
+// Shared satisfies both tql.Querier and tql.Executor, so it can be used to set up the data
+// visible to all the tests.
+//
+// Tests using the same Shared must not run in parallel.
+type Shared struct {
+	db   *sql.DB
+	conn *sql.Conn
+	tx   *sql.Tx
+
+	savepoints atomic.Int64
+}
+
+// NewShared
+// Reserves a connection from db and begins the shared transaction on it.
+func NewShared(ctx context.Context, db *sql.DB) (*Shared, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+
+	return &Shared{db: db, conn: conn, tx: tx}, nil
+}
+
+// Close
+// Rolls back the shared transaction and releases the connection.
+func (s *Shared) Close() error {
+	return errors.Join(s.tx.Rollback(), s.conn.Close())
+}
+
+// Savepoint
+// Creates a savepoint which is rolled back once the test and all its subtests finish.
+func (s *Shared) Savepoint(t testing.TB) *Savepoint {
+	t.Helper()
+
+	name := fmt.Sprintf("tqltest_%d", s.savepoints.Add(1))
+
+	if _, err := s.tx.ExecContext(context.Background(), "SAVEPOINT "+name); err != nil {
+		t.Fatalf("tqltest: failed to create savepoint: %s", err.Error())
+	}
+
+	t.Cleanup(func() {
+		ctx := context.Background()
+
+		if _, err := s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); err != nil {
+			t.Errorf("tqltest: failed to roll back to savepoint: %s", err.Error())
+			return
+		}
+
+		if _, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+			t.Errorf("tqltest: failed to release savepoint: %s", err.Error())
+		}
+	})
+
+	return &Savepoint{db: s.db, tx: s.tx}
+}
+
+// Dialect
+// Returns the dialect of the database the connection was reserved from, or nil if tql can't tell it.
+func (s *Shared) Dialect() tql.Dialect {
+	return dialectOf(s.db)
+}
+
+func (s *Shared) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return s.tx.QueryContext(ctx, query, args...)
+}
+
+func (s *Shared) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (s *Shared) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.tx.ExecContext(ctx, query, args...)
+}
+
+// Savepoint
+// Runs the queries of a single test in the shared transaction. It satisfies both tql.Querier
+// and tql.Executor, but can not be committed.
+type Savepoint struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// Dialect
+// Returns the dialect of the database the shared connection was reserved from, or nil if tql can't tell it.
+func (s *Savepoint) Dialect() tql.Dialect {
+	return dialectOf(s.db)
+}
+
+func (s *Savepoint) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return s.tx.QueryContext(ctx, query, args...)
+}
+
+func (s *Savepoint) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (s *Savepoint) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.tx.ExecContext(ctx, query, args...)
+}
+
+// dialectOf
+// Returns the dialect of the database, or nil so tql chooses it as for any other handle.
+func dialectOf(db any) tql.Dialect {
+	d, err := tql.DialectOf(db)
+	if err != nil {
+		return nil
+	}
+
+	return d
+}
@@ -0,0 +1,170 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Tuple2
+// A row of two columns returned by tql.Query2.
+type Tuple2[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Tuple3
+// A row of three columns returned by tql.Query3.
+type Tuple3[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// QueryRow2
+// Queries the database and scans the two columns of the first row into the values, e.g.
+//
+//	count, latest, err := tql.QueryRow2[int, time.Time](ctx, db, "SELECT count(*), max(created_at) FROM foo;")
+//
+// The columns are scanned in the same way as the single column of a basic type in tql.QueryFirst,
+// structs mapped by their fields can't be used as the values. If the query returns no results,
+// the function returns sql.ErrNoRows. If the query doesn't return exactly two columns, an error
+// is returned.
+func QueryRow2[A, B any](ctx context.Context, q Querier, query string, params ...any) (A, B, error) {
+	var t Tuple2[A, B]
+
+	if err := checkScalars(reflect.TypeFor[A](), reflect.TypeFor[B]()); err != nil {
+		return t.First, t.Second, err
+	}
+
+	err := queryRows(ctx, q, query, params, func(rows *sql.Rows) error {
+		return scanFirstRow(rows, &t.First, &t.Second)
+	})
+
+	return t.First, t.Second, err
+}
+
+// QueryRow3
+// A variant of QueryRow2 scanning the three columns of the first row into the values.
+func QueryRow3[A, B, C any](ctx context.Context, q Querier, query string, params ...any) (A, B, C, error) {
+	var t Tuple3[A, B, C]
+
+	if err := checkScalars(reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]()); err != nil {
+		return t.First, t.Second, t.Third, err
+	}
+
+	err := queryRows(ctx, q, query, params, func(rows *sql.Rows) error {
+		return scanFirstRow(rows, &t.First, &t.Second, &t.Third)
+	})
+
+	return t.First, t.Second, t.Third, err
+}
+
+// Query2
+// Queries the database and returns all the rows of two columns as a slice of tuples. If the query
+// returns no results, an empty slice is returned. If the query doesn't return exactly two columns,
+// an error is returned.
+func Query2[A, B any](ctx context.Context, q Querier, query string, params ...any) ([]Tuple2[A, B], error) {
+	result := make([]Tuple2[A, B], 0)
+
+	if err := checkScalars(reflect.TypeFor[A](), reflect.TypeFor[B]()); err != nil {
+		return result, err
+	}
+
+	err := queryRows(ctx, q, query, params, func(rows *sql.Rows) error {
+		if err := checkColumns(rows, 2); err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var t Tuple2[A, B]
+			if err := rows.Scan(&t.First, &t.Second); err != nil {
+				return err
+			}
+
+			result = append(result, t)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// Query3
+// A variant of Query2 returning all the rows of three columns as a slice of tuples.
+func Query3[A, B, C any](ctx context.Context, q Querier, query string, params ...any) ([]Tuple3[A, B, C], error) {
+	result := make([]Tuple3[A, B, C], 0)
+
+	if err := checkScalars(reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]()); err != nil {
+		return result, err
+	}
+
+	err := queryRows(ctx, q, query, params, func(rows *sql.Rows) error {
+		if err := checkColumns(rows, 3); err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var t Tuple3[A, B, C]
+			if err := rows.Scan(&t.First, &t.Second, &t.Third); err != nil {
+				return err
+			}
+
+			result = append(result, t)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// scanFirstRow
+// Scans the columns of the first row into dest, returning sql.ErrNoRows if there are no rows.
+func scanFirstRow(rows *sql.Rows, dest ...any) error {
+	if err := checkColumns(rows, len(dest)); err != nil {
+		return err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	return rows.Scan(dest...)
+}
+
+// checkScalars
+// Returns an error if any of the types is a struct mapped by its fields, as every value
+// is scanned from a single column.
+func checkScalars(types ...reflect.Type) error {
+	for _, typ := range types {
+		if _, _, mapped := mappedStruct(typ); mapped {
+			return fmt.Errorf(
+				"invalid type %s: tuple values are scanned from a single column, use tql.Query for structs mapped by their fields",
+				typ,
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkColumns
+// Returns an error if the rows don't have the expected number of columns.
+func checkColumns(rows *sql.Rows, expected int) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if len(cols) != expected {
+		return fmt.Errorf("expected %d columns, query returned %d columns", expected, len(cols))
+	}
+
+	return nil
+}
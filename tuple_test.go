@@ -0,0 +1,211 @@
+package tql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_QueryRow2_Scans_Columns(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	latest := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	db, connector := newFakeDB(fakeResultSet{
+		columns: []string{"count", "max"},
+		rows:    [][]driver.Value{{int64(3), latest}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	count, found, err := QueryRow2[int, time.Time](
+		context.Background(),
+		db,
+		"SELECT count(*), max(created_at) FROM foo WHERE name = :name;",
+		map[string]any{"name": "foo"},
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if count != 3 || !found.Equal(latest) {
+		t.Fatalf("unexpected values '%d' '%s'", count, found)
+	}
+
+	const expectedQuery = "SELECT count(*), max(created_at) FROM foo WHERE name = $1;"
+	if connector.query != expectedQuery {
+		t.Fatalf("value '%s' does not equal expected '%s'", connector.query, expectedQuery)
+	}
+}
+
+func Test_QueryRow3_Scans_Columns(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name", "nullable"},
+		rows:    [][]driver.Value{{"1", "foo", nil}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	id, name, nullable, err := QueryRow3[string, string, *string](context.Background(), db, "SELECT id, name, nullable FROM foo;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	if id != "1" || name != "foo" || nullable != nil {
+		t.Fatalf("unexpected values '%s' '%s' '%v'", id, name, nullable)
+	}
+}
+
+func Test_QueryRow2_No_Rows(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{columns: []string{"id", "name"}})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, _, err := QueryRow2[string, string](context.Background(), db, "SELECT id, name FROM foo;")
+
+	// Assert
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("value '%v' does not equal expected '%v'", err, sql.ErrNoRows)
+	}
+}
+
+func Test_QueryRow2_Column_Count_Mismatch(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name", "nullable"},
+		rows:    [][]driver.Value{{"1", "foo", nil}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, _, err := QueryRow2[string, string](context.Background(), db, "SELECT id, name, nullable FROM foo;")
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_Query2_Returns_Tuples(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"name", "count"},
+		rows:    [][]driver.Value{{"foo", int64(1)}, {"bar", int64(2)}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	results, err := Query2[string, int64](context.Background(), db, "SELECT name, count(*) FROM foo GROUP BY name;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expected := []Tuple2[string, int64]{{First: "foo", Second: 1}, {First: "bar", Second: 2}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", results, expected)
+	}
+}
+
+func Test_Query3_Returns_Tuples(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name", "count"},
+		rows:    [][]driver.Value{{"1", "foo", int64(1)}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	results, err := Query3[string, string, int](context.Background(), db, "SELECT id, name, count FROM foo;")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err.Error())
+	}
+
+	expected := []Tuple3[string, string, int]{{First: "1", Second: "foo", Third: 1}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("value '%v' does not equal expected '%v'", results, expected)
+	}
+}
+
+func Test_Query3_Column_Count_Mismatch(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, _ := newFakeDB(fakeResultSet{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{"1", "foo"}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, err := Query3[string, string, int](context.Background(), db, "SELECT id, name FROM foo;")
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected error, found nil")
+	}
+}
+
+func Test_Query2_Rejects_Mapped_Structs(t *testing.T) {
+	// Arrange
+	if err := SetActiveDriver("postgres"); err != nil {
+		t.Fatalf("failed to set driver: %s", err.Error())
+	}
+
+	db, connector := newFakeDB(fakeResultSet{
+		columns: []string{"id", "count"},
+		rows:    [][]driver.Value{{"1", int64(3)}},
+	})
+	defer func() { _ = db.Close() }()
+
+	// Act
+	_, err := Query2[pointerResult, int](context.Background(), db, "SELECT id, count FROM foo;")
+	_, _, _, rowErr := QueryRow3[int, string, *pointerResult](context.Background(), db, "SELECT 1, id, name FROM foo;")
+
+	// Assert
+	if err == nil || rowErr == nil {
+		t.Fatalf("expected error, found nil")
+	}
+
+	if connector.query != "" {
+		t.Fatalf("unexpected query '%s'", connector.query)
+	}
+}